@@ -3,6 +3,7 @@ package csvframer
 import "errors"
 
 var (
-	ErrEmptyCsv = errors.New("empty/invalid csv")
-	ErrReadingCsvResponse = errors.New("error reading csv response")
-)
\ No newline at end of file
+	ErrEmptyCsv              = errors.New("empty/invalid csv")
+	ErrReadingCsvResponse    = errors.New("error reading csv response")
+	ErrMaxErrorRatioExceeded = errors.New("csv lines with errors exceeded the maximum error ratio")
+)
@@ -9,6 +9,7 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/infinity-libs/lib/go/gframer"
+	"github.com/grafana/infinity-libs/lib/go/utils"
 )
 
 type FramerOptions struct {
@@ -16,14 +17,85 @@ type FramerOptions struct {
 	Columns            []gframer.ColumnSelector
 	Delimiter          string
 	SkipLinesWithError bool
-	Comment            string
-	RelaxColumnCount   bool
-	NoHeaders          bool
+	// MaxErrorRatio is the maximum allowed ratio (0 to 1) of skipped lines to the total lines read
+	// when SkipLinesWithError is enabled. Zero means no limit.
+	MaxErrorRatio    float64
+	Comment          string
+	RelaxColumnCount bool
+	NoHeaders        bool
+	// Encoding is the character encoding of the csv input. `utf-8` (default), `utf-16le`, `utf-16be`, `latin1`, `windows-1252` or `auto`
+	Encoding string
+}
+
+// maxSkippedLineNotices limits the number of per-line notices attached to the frame
+const maxSkippedLineNotices = 10
+
+// SkippedLine represents a csv line dropped because of a parse error
+type SkippedLine struct {
+	Line  int
+	Error string
+}
+
+// ParseReport holds the diagnostics collected while reading the csv
+type ParseReport struct {
+	TotalLines   int
+	SkippedLines []SkippedLine
+}
+
+// ErrorRatio returns the ratio of skipped lines to the total lines read
+func (r ParseReport) ErrorRatio() float64 {
+	if r.TotalLines == 0 {
+		return 0
+	}
+	return float64(len(r.SkippedLines)) / float64(r.TotalLines)
+}
+
+// Notices converts the report into frame notices
+func (r ParseReport) Notices() []data.Notice {
+	if len(r.SkippedLines) == 0 {
+		return nil
+	}
+	lines := []string{}
+	for idx, l := range r.SkippedLines {
+		if idx >= maxSkippedLineNotices {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%d", l.Line))
+	}
+	more := ""
+	if len(r.SkippedLines) > maxSkippedLineNotices {
+		more = fmt.Sprintf(" and %d more", len(r.SkippedLines)-maxSkippedLineNotices)
+	}
+	notices := []data.Notice{{
+		Severity: data.NoticeSeverityWarning,
+		Text:     fmt.Sprintf("skipped %d of %d csv line(s) with errors. lines: %s%s", len(r.SkippedLines), r.TotalLines, strings.Join(lines, ","), more),
+	}}
+	for idx, l := range r.SkippedLines {
+		if idx >= maxSkippedLineNotices {
+			break
+		}
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("line %d: %s", l.Line, l.Error),
+		})
+	}
+	return notices
 }
 
 func ToFrame(csvString string, options FramerOptions) (frame *data.Frame, err error) {
+	frame, _, err = ToFrameWithReport(csvString, options)
+	return frame, err
+}
+
+// ToFrameWithReport converts the csv string into a data frame and additionally returns the report of skipped lines.
+// Skipped lines are also attached to the frame as notices.
+func ToFrameWithReport(csvString string, options FramerOptions) (frame *data.Frame, report ParseReport, err error) {
+	csvString, err = utils.DecodeString(csvString, utils.Encoding(options.Encoding))
+	if err != nil {
+		return frame, report, errors.Join(ErrReadingCsvResponse, err)
+	}
 	if strings.TrimSpace(csvString) == "" {
-		return frame, ErrEmptyCsv
+		return frame, report, ErrEmptyCsv
 	}
 	r := csv.NewReader(strings.NewReader(csvString))
 	r.LazyQuotes = true
@@ -42,13 +114,31 @@ func ToFrame(csvString string, options FramerOptions) (frame *data.Frame, err er
 		if err == io.EOF {
 			break
 		}
+		report.TotalLines++
 		if err == nil {
 			parsedCSV = append(parsedCSV, record)
 			continue
 		}
 		if !options.SkipLinesWithError {
-			return frame, errors.Join(ErrReadingCsvResponse, fmt.Errorf("%w, %v", err, record))
+			return frame, report, errors.Join(ErrReadingCsvResponse, fmt.Errorf("%w, %v", err, record))
+		}
+		line := 0
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			line = parseErr.StartLine
+			err = parseErr.Err
+		}
+		report.SkippedLines = append(report.SkippedLines, SkippedLine{Line: line, Error: err.Error()})
+	}
+	if options.MaxErrorRatio > 0 && report.ErrorRatio() > options.MaxErrorRatio {
+		return frame, report, errors.Join(ErrReadingCsvResponse, fmt.Errorf("%w. skipped %d of %d lines", ErrMaxErrorRatioExceeded, len(report.SkippedLines), report.TotalLines))
+	}
+	if len(parsedCSV) == 0 {
+		frame = data.NewFrame(options.FrameName)
+		if len(report.SkippedLines) > 0 {
+			frame.AppendNotices(report.Notices()...)
 		}
+		return frame, report, nil
 	}
 	out := []interface{}{}
 	header := []string{}
@@ -85,5 +175,9 @@ func ToFrame(csvString string, options FramerOptions) (frame *data.Frame, err er
 		FrameName: options.FrameName,
 		Columns:   options.Columns,
 	}
-	return gframer.ToDataFrame(out, framerOptions)
+	frame, err = gframer.ToDataFrame(out, framerOptions)
+	if frame != nil && len(report.SkippedLines) > 0 {
+		frame.AppendNotices(report.Notices()...)
+	}
+	return frame, report, err
 }
@@ -90,3 +90,164 @@ func TestCsvStringToFrame(t *testing.T) {
 		})
 	}
 }
+
+func TestCsvStringToFrameWithReport(t *testing.T) {
+	tests := []struct {
+		name             string
+		csvString        string
+		options          csvframer.FramerOptions
+		wantSkippedLines []csvframer.SkippedLine
+		wantRows         int
+		wantNotices      int
+		wantError        error
+	}{
+		{
+			name:      "valid csv should not report skipped lines",
+			csvString: strings.Join([]string{`a,b,c`, `1,2,3`, `11,12,13`}, "\n"),
+			options:   csvframer.FramerOptions{SkipLinesWithError: true},
+			wantRows:  2,
+		},
+		{
+			name:             "lines with errors should be reported",
+			csvString:        strings.Join([]string{`a,b,c`, `1,2,3`, `11,12`, `21,22,23`, `31`}, "\n"),
+			options:          csvframer.FramerOptions{SkipLinesWithError: true},
+			wantSkippedLines: []csvframer.SkippedLine{{Line: 3, Error: "wrong number of fields"}, {Line: 5, Error: "wrong number of fields"}},
+			wantRows:         2,
+			wantNotices:      3,
+		},
+		{
+			name:      "csv without records should not panic",
+			csvString: `# foo`,
+			options:   csvframer.FramerOptions{Comment: "#"},
+		},
+		{
+			name:      "error ratio above the maximum should fail",
+			csvString: strings.Join([]string{`a,b,c`, `1,2,3`, `11,12`, `21,22`}, "\n"),
+			options:   csvframer.FramerOptions{SkipLinesWithError: true, MaxErrorRatio: 0.4},
+			wantError: csvframer.ErrMaxErrorRatioExceeded,
+		},
+		{
+			name:             "error ratio below the maximum should not fail",
+			csvString:        strings.Join([]string{`a,b,c`, `1,2,3`, `11,12`, `21,22`}, "\n"),
+			options:          csvframer.FramerOptions{SkipLinesWithError: true, MaxErrorRatio: 0.5},
+			wantSkippedLines: []csvframer.SkippedLine{{Line: 3, Error: "wrong number of fields"}, {Line: 4, Error: "wrong number of fields"}},
+			wantRows:         1,
+			wantNotices:      3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFrame, report, err := csvframer.ToFrameWithReport(tt.csvString, tt.options)
+			if tt.wantError != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.wantError)
+				assert.ErrorIs(t, err, csvframer.ErrReadingCsvResponse)
+				return
+			}
+			require.Nil(t, err)
+			require.NotNil(t, gotFrame)
+			assert.Equal(t, tt.wantSkippedLines, report.SkippedLines)
+			assert.Equal(t, tt.wantRows, gotFrame.Rows())
+			if tt.wantNotices == 0 {
+				assert.Nil(t, gotFrame.Meta)
+				return
+			}
+			require.NotNil(t, gotFrame.Meta)
+			assert.Len(t, gotFrame.Meta.Notices, tt.wantNotices)
+		})
+	}
+	t.Run("summary notice should list limited line numbers", func(t *testing.T) {
+		lines := []string{`a,b,c`}
+		for i := 0; i < 25; i++ {
+			lines = append(lines, `1,2`)
+		}
+		gotFrame, report, err := csvframer.ToFrameWithReport(strings.Join(lines, "\n"), csvframer.FramerOptions{SkipLinesWithError: true})
+		require.Nil(t, err)
+		require.Len(t, report.SkippedLines, 25)
+		require.NotNil(t, gotFrame.Meta)
+		assert.Len(t, gotFrame.Meta.Notices, 11)
+		assert.Equal(t, "skipped 25 of 26 csv line(s) with errors. lines: 2,3,4,5,6,7,8,9,10,11 and 15 more", gotFrame.Meta.Notices[0].Text)
+	})
+}
+
+func TestCsvStringToFrameWithEncoding(t *testing.T) {
+	utf16le := func(s string) string {
+		out := []byte{0xFF, 0xFE}
+		for _, r := range s {
+			out = append(out, byte(r), byte(r>>8))
+		}
+		return string(out)
+	}
+	tests := []struct {
+		name       string
+		csvString  string
+		encoding   string
+		wantHeader string
+		wantValue  string
+		wantError  error
+	}{
+		{
+			name:       "utf-8 bom should be removed from the header",
+			csvString:  "\xEF\xBB\xBFname,city\nfoo,Zürich",
+			wantHeader: "name",
+			wantValue:  "Zürich",
+		},
+		{
+			name:       "utf-16le with bom should be transcoded",
+			csvString:  utf16le("name,city\nfoo,Zürich"),
+			encoding:   "utf-16le",
+			wantHeader: "name",
+			wantValue:  "Zürich",
+		},
+		{
+			name:       "utf-16le with bom should be detected automatically",
+			csvString:  utf16le("name,city\nfoo,Zürich"),
+			encoding:   "auto",
+			wantHeader: "name",
+			wantValue:  "Zürich",
+		},
+		{
+			name:       "windows-1252 should be transcoded",
+			csvString:  "name,city\nfoo,Z\xFCrich \x80",
+			encoding:   "windows-1252",
+			wantHeader: "name",
+			wantValue:  "Zürich €",
+		},
+		{
+			name:       "encoding name should be case insensitive",
+			csvString:  utf16le("name,city\nfoo,Zürich"),
+			encoding:   "AUTO",
+			wantHeader: "name",
+			wantValue:  "Zürich",
+		},
+		{
+			name:       "input without bom should be treated as utf-8 automatically",
+			csvString:  "name,city\nfoo,Zürich",
+			encoding:   "Auto",
+			wantHeader: "name",
+			wantValue:  "Zürich",
+		},
+		{
+			name:      "unsupported encoding should return error",
+			csvString: "name,city\nfoo,bar",
+			encoding:  "ebcdic",
+			wantError: csvframer.ErrReadingCsvResponse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFrame, err := csvframer.ToFrame(tt.csvString, csvframer.FramerOptions{Encoding: tt.encoding})
+			if tt.wantError != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.wantError)
+				return
+			}
+			require.Nil(t, err)
+			require.NotNil(t, gotFrame)
+			require.Len(t, gotFrame.Fields, 2)
+			assert.Equal(t, "city", gotFrame.Fields[0].Name)
+			assert.Equal(t, tt.wantHeader, gotFrame.Fields[1].Name)
+			assert.Equal(t, tt.wantValue, *(gotFrame.Fields[0].At(0).(*string)))
+		})
+	}
+}
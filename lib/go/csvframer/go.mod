@@ -5,6 +5,7 @@ go 1.26.3
 require (
 	github.com/grafana/grafana-plugin-sdk-go v0.292.1
 	github.com/grafana/infinity-libs/lib/go/gframer v1.1.2
+	github.com/grafana/infinity-libs/lib/go/utils v1.0.1
 	github.com/stretchr/testify v1.11.1
 )
 
@@ -27,7 +28,6 @@ require (
 	github.com/google/flatbuffers v25.12.19+incompatible // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/grafana/infinity-libs/lib/go/utils v1.0.1 // indirect
 	github.com/grafana/otel-profiling-go v0.5.3 // indirect
 	github.com/grafana/pyroscope-go/godeltaprof v0.1.11 // indirect
 	github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus v1.1.0 // indirect
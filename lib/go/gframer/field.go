@@ -47,6 +47,9 @@ func anyToNullableBool(input []any, fieldName string, labels data.Labels, o []in
 			if ok && strings.ToLower(val) == "true" {
 				field.Set(i, pointer(true))
 			}
+			if ok && strings.ToLower(val) == "false" {
+				field.Set(i, pointer(false))
+			}
 		default:
 			noOperation(cvt)
 			field.Set(i, nil)
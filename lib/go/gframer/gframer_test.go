@@ -174,3 +174,33 @@ func TestJsonFieldType(t *testing.T) {
 		experimental.CheckGoldenJSONFrame(t, "testdata/jsonfield", strings.ReplaceAll(t.Name(), "TestJsonFieldType/", ""), gotFrame, true)
 	})
 }
+
+func TestBooleanFieldType(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+		want  *bool
+	}{
+		{name: "true string", input: "true", want: pointer(true)},
+		{name: "true string with different case", input: "TRUE", want: pointer(true)},
+		{name: "false string", input: "false", want: pointer(false)},
+		{name: "false string with different case", input: "False", want: pointer(false)},
+		{name: "empty string", input: "", want: nil},
+		{name: "invalid string", input: "yes", want: nil},
+		{name: "boolean", input: false, want: pointer(false)},
+		{name: "null", input: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFrame, err := gframer.ToDataFrame([]any{map[string]any{"bool": tt.input}}, gframer.FramerOptions{Columns: []gframer.ColumnSelector{{Selector: "bool", Type: "boolean"}}})
+			require.Nil(t, err)
+			require.NotNil(t, gotFrame)
+			require.Len(t, gotFrame.Fields, 1)
+			require.Equal(t, tt.want, gotFrame.Fields[0].At(0))
+		})
+	}
+}
+
+func pointer[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,25 @@
+package macros
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// unresolvedVariableRegex matches the template variable left as it is because the variable is not available
+var unresolvedVariableRegex = regexp.MustCompile(`^\$(\w+|\{\w+(:[^}]*)?\})$`)
+
+// ifMacro expands $__if(condition, text). The text is returned when the condition is not empty, otherwise empty string is returned.
+// Condition with unresolved template variable such as ${region} is treated as empty.
+// Example: $__if(${region}, &region=${region})
+func ifMacro(args []string, options Args) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("%w: $__if expects condition and text arguments", ErrInvalidMacroArguments)
+	}
+	condition := strings.TrimSpace(args[0])
+	if condition == "" || unresolvedVariableRegex.MatchString(condition) {
+		return "", nil
+	}
+	// text may contain commas. Example: $__if(${hosts}, hosts=${hosts:csv})
+	return args[1], nil
+}
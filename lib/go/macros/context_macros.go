@@ -0,0 +1,67 @@
+package macros
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// userMacro returns the macro function for the user fields. The macro is kept as it is when the user is not available
+func userMacro(field func(u *backend.User) string) MacroFunc {
+	return func(args []string, options Args) (string, error) {
+		if options.User == nil {
+			return "", errMacroNotApplied
+		}
+		return field(options.User), nil
+	}
+}
+
+func orgIDMacro(args []string, options Args) (string, error) {
+	if options.PluginContext == nil {
+		return "", errMacroNotApplied
+	}
+	return fmt.Sprintf("%d", options.PluginContext.OrgID), nil
+}
+
+func orgNameMacro(args []string, options Args) (string, error) {
+	if options.OrgName == "" {
+		return "", errMacroNotApplied
+	}
+	return options.OrgName, nil
+}
+
+// dataSourceMacro returns the macro function for the datasource fields. The macro is kept as it is when the datasource is not available
+func dataSourceMacro(field func(ds *backend.DataSourceInstanceSettings) string) MacroFunc {
+	return func(args []string, options Args) (string, error) {
+		if options.PluginContext == nil || options.PluginContext.DataSourceInstanceSettings == nil {
+			return "", errMacroNotApplied
+		}
+		return field(options.PluginContext.DataSourceInstanceSettings), nil
+	}
+}
+
+// queryMacro returns the macro function for the query fields. The macro is kept as it is when the query is not available
+func queryMacro(field func(q *backend.DataQuery) string) MacroFunc {
+	return func(args []string, options Args) (string, error) {
+		if options.Query == nil {
+			return "", errMacroNotApplied
+		}
+		return field(options.Query), nil
+	}
+}
+
+// withContextDefaults fills the time range, user and max data points from the query and plugin context when not set
+func (args Args) withContextDefaults() Args {
+	if args.Query != nil {
+		if args.TimeRange.From.IsZero() && args.TimeRange.To.IsZero() {
+			args.TimeRange = args.Query.TimeRange
+		}
+		if args.MaxDataPoints == 0 {
+			args.MaxDataPoints = args.Query.MaxDataPoints
+		}
+	}
+	if args.User == nil && args.PluginContext != nil {
+		args.User = args.PluginContext.User
+	}
+	return args
+}
@@ -0,0 +1,123 @@
+package macros
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DiagnosticKind is the kind of the problem found in the macro
+type DiagnosticKind string
+
+const (
+	DiagnosticKindUnknownMacro          DiagnosticKind = "unknown-macro"
+	DiagnosticKindBadArguments          DiagnosticKind = "bad-arguments"
+	DiagnosticKindUnbalancedParentheses DiagnosticKind = "unbalanced-parentheses"
+	DiagnosticKindUnbalancedBraces      DiagnosticKind = "unbalanced-braces"
+)
+
+// Diagnostic represents a problem found in the macro. Offset is the byte offset of the macro in the input string
+type Diagnostic struct {
+	Macro   string
+	Offset  int
+	Kind    DiagnosticKind
+	Message string
+}
+
+func (d Diagnostic) Error() string {
+	msg := fmt.Sprintf("%s %q at offset %d", d.Kind, d.Macro, d.Offset)
+	if d.Message != "" {
+		msg = fmt.Sprintf("%s. %s", msg, d.Message)
+	}
+	return msg
+}
+
+// ApplyMacrosWithDiagnostics applies the built-in macros same as ApplyMacros and additionally returns the diagnostics of unknown macros,
+// malformed arguments and unbalanced parentheses/braces. Macros with bad arguments are reported as diagnostics and kept as it is instead of failing,
+// unless strict mode is enabled.
+func ApplyMacrosWithDiagnostics(input string, args Args) (string, []Diagnostic, error) {
+	return defaultRegistry.ApplyWithDiagnostics(input, args)
+}
+
+// macroToken is a macro occurrence found in the input string
+type macroToken struct {
+	name   string
+	offset int
+	syntax Syntax
+	// closed denotes whether the closing parenthesis / brace of the macro is found
+	closed bool
+}
+
+// scanMacros finds the $__name, $__name(args), ${__name} and ${__name:format} macros in the input string
+func scanMacros(input string) []macroToken {
+	tokens := []macroToken{}
+	for i := 0; i < len(input); i++ {
+		if input[i] != '$' {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(input[i:], "${__"):
+			name := scanMacroName(input[i+4:])
+			end := strings.IndexByte(input[i:], '}')
+			tokens = append(tokens, macroToken{name: name, offset: i, syntax: SyntaxVariable, closed: end >= 0})
+			if end >= 0 {
+				i += end
+			}
+		case strings.HasPrefix(input[i:], "$__"):
+			name := scanMacroName(input[i+3:])
+			token := macroToken{name: name, offset: i, syntax: SyntaxFunction, closed: true}
+			next := i + 3 + len(name)
+			if next < len(input) && input[next] == '(' {
+				end := findClosingParenthesis(input, next)
+				token.closed = end >= 0
+			}
+			tokens = append(tokens, token)
+			i = next - 1
+		}
+	}
+	return tokens
+}
+
+// scanMacroName returns the leading macro name. Trailing dots are not part of the name
+func scanMacroName(input string) string {
+	end := 0
+	for end < len(input) && isMacroNameChar(input[end]) {
+		end++
+	}
+	return strings.TrimRight(input[:end], ".")
+}
+
+func isMacroNameChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// diagnose returns the diagnostics of unknown and unbalanced macros found in the input
+func (r *Registry) diagnose(tokens []macroToken) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, token := range tokens {
+		macro := "$__" + token.name
+		if token.syntax == SyntaxVariable {
+			macro = "${__" + token.name + "}"
+		}
+		switch {
+		case !token.closed && token.syntax == SyntaxVariable:
+			diagnostics = append(diagnostics, Diagnostic{Macro: macro, Offset: token.offset, Kind: DiagnosticKindUnbalancedBraces, Message: "missing closing brace"})
+		case !token.closed:
+			diagnostics = append(diagnostics, Diagnostic{Macro: macro, Offset: token.offset, Kind: DiagnosticKindUnbalancedParentheses, Message: "missing closing parenthesis"})
+		case !r.has(token.name, token.syntax):
+			diagnostics = append(diagnostics, Diagnostic{Macro: macro, Offset: token.offset, Kind: DiagnosticKindUnknownMacro})
+		}
+	}
+	return diagnostics
+}
+
+// isBadArguments checks whether the macro error is caused by the macro arguments
+func isBadArguments(err error) bool {
+	return errors.Is(err, ErrInvalidMacroArguments) || errors.Is(err, ErrInvalidRelativeTime)
+}
+
+func sortDiagnostics(diagnostics []Diagnostic) []Diagnostic {
+	slices.SortStableFunc(diagnostics, func(a, b Diagnostic) int { return a.Offset - b.Offset })
+	return slices.CompactFunc(diagnostics, func(a, b Diagnostic) bool { return a.Offset == b.Offset && a.Offset >= 0 })
+}
@@ -0,0 +1,114 @@
+package macros
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+var (
+	DialectANSI       Dialect = ansiDialect{}
+	DialectPostgres   Dialect = postgresDialect{}
+	DialectMySQL      Dialect = mysqlDialect{}
+	DialectClickHouse Dialect = clickHouseDialect{}
+)
+
+const sqlTimestampLayout = "2006-01-02 15:04:05.999"
+
+type ansiDialect struct{}
+
+func (d ansiDialect) TimeFilter(column string, timeRange backend.TimeRange) string {
+	return fmt.Sprintf("%s BETWEEN %s AND %s", column, d.TimeFrom(timeRange), d.TimeTo(timeRange))
+}
+
+func (ansiDialect) TimeGroup(column string, interval time.Duration) string {
+	return fmt.Sprintf("FLOOR(EXTRACT(EPOCH FROM %s) / %d) * %d", column, intervalSeconds(interval), intervalSeconds(interval))
+}
+
+func (ansiDialect) UnixEpochFilter(column string, timeRange backend.TimeRange) string {
+	return unixEpochFilter(column, timeRange)
+}
+
+func (ansiDialect) TimeFrom(timeRange backend.TimeRange) string {
+	return fmt.Sprintf("TIMESTAMP '%s'", timeRange.From.UTC().Format(sqlTimestampLayout))
+}
+
+func (ansiDialect) TimeTo(timeRange backend.TimeRange) string {
+	return fmt.Sprintf("TIMESTAMP '%s'", timeRange.To.UTC().Format(sqlTimestampLayout))
+}
+
+type postgresDialect struct{}
+
+func (d postgresDialect) TimeFilter(column string, timeRange backend.TimeRange) string {
+	return fmt.Sprintf("%s BETWEEN %s AND %s", column, d.TimeFrom(timeRange), d.TimeTo(timeRange))
+}
+
+func (postgresDialect) TimeGroup(column string, interval time.Duration) string {
+	return fmt.Sprintf("floor(extract(epoch from %s)/%d)*%d", column, intervalSeconds(interval), intervalSeconds(interval))
+}
+
+func (postgresDialect) UnixEpochFilter(column string, timeRange backend.TimeRange) string {
+	return unixEpochFilter(column, timeRange)
+}
+
+func (postgresDialect) TimeFrom(timeRange backend.TimeRange) string {
+	return fmt.Sprintf("'%s'", timeRange.From.UTC().Format(time.RFC3339Nano))
+}
+
+func (postgresDialect) TimeTo(timeRange backend.TimeRange) string {
+	return fmt.Sprintf("'%s'", timeRange.To.UTC().Format(time.RFC3339Nano))
+}
+
+type mysqlDialect struct{}
+
+func (d mysqlDialect) TimeFilter(column string, timeRange backend.TimeRange) string {
+	return fmt.Sprintf("%s BETWEEN %s AND %s", column, d.TimeFrom(timeRange), d.TimeTo(timeRange))
+}
+
+func (mysqlDialect) TimeGroup(column string, interval time.Duration) string {
+	return fmt.Sprintf("UNIX_TIMESTAMP(%s) DIV %d * %d", column, intervalSeconds(interval), intervalSeconds(interval))
+}
+
+func (mysqlDialect) UnixEpochFilter(column string, timeRange backend.TimeRange) string {
+	return unixEpochFilter(column, timeRange)
+}
+
+func (mysqlDialect) TimeFrom(timeRange backend.TimeRange) string {
+	return fmt.Sprintf("FROM_UNIXTIME(%d)", timeRange.From.Unix())
+}
+
+func (mysqlDialect) TimeTo(timeRange backend.TimeRange) string {
+	return fmt.Sprintf("FROM_UNIXTIME(%d)", timeRange.To.Unix())
+}
+
+type clickHouseDialect struct{}
+
+func (d clickHouseDialect) TimeFilter(column string, timeRange backend.TimeRange) string {
+	return fmt.Sprintf("%s >= %s AND %s <= %s", column, d.TimeFrom(timeRange), column, d.TimeTo(timeRange))
+}
+
+func (clickHouseDialect) TimeGroup(column string, interval time.Duration) string {
+	return fmt.Sprintf("toStartOfInterval(%s, INTERVAL %d second)", column, intervalSeconds(interval))
+}
+
+func (clickHouseDialect) UnixEpochFilter(column string, timeRange backend.TimeRange) string {
+	return unixEpochFilter(column, timeRange)
+}
+
+func (clickHouseDialect) TimeFrom(timeRange backend.TimeRange) string {
+	return fmt.Sprintf("toDateTime(%d)", timeRange.From.Unix())
+}
+
+func (clickHouseDialect) TimeTo(timeRange backend.TimeRange) string {
+	return fmt.Sprintf("toDateTime(%d)", timeRange.To.Unix())
+}
+
+func unixEpochFilter(column string, timeRange backend.TimeRange) string {
+	return fmt.Sprintf("%s >= %d AND %s <= %d", column, timeRange.From.Unix(), column, timeRange.To.Unix())
+}
+
+// intervalSeconds returns the interval in seconds. Minimum is 1 second
+func intervalSeconds(interval time.Duration) int64 {
+	return max(int64(interval.Seconds()), 1)
+}
@@ -0,0 +1,16 @@
+package macros
+
+import "errors"
+
+var (
+	ErrInvalidRelativeTime   = errors.New("invalid relative time expression")
+	ErrInvalidMacroArguments = errors.New("invalid macro arguments")
+	ErrInvalidMacroName      = errors.New("invalid macro name")
+	ErrInvalidMacro          = errors.New("invalid macro")
+	ErrInvalidEscapeContext  = errors.New("invalid escape context")
+	ErrInvalidTimezone       = errors.New("invalid timezone")
+	ErrMacroDiagnostics      = errors.New("invalid macros found")
+
+	ErrInvalidPaginationOptions = errors.New("invalid pagination options")
+	ErrFetchingPage             = errors.New("error fetching page")
+)
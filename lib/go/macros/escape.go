@@ -0,0 +1,43 @@
+package macros
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// EscapeContext is the target context of the macro output such as url query or json string
+type EscapeContext string
+
+const (
+	EscapeContextRaw        EscapeContext = "raw"
+	EscapeContextURLQuery   EscapeContext = "url-query"
+	EscapeContextURLPath    EscapeContext = "url-path"
+	EscapeContextJSONString EscapeContext = "json-string"
+	EscapeContextSQLLiteral EscapeContext = "sql-literal"
+)
+
+func (c EscapeContext) validate() error {
+	switch c {
+	case "", EscapeContextRaw, EscapeContextURLQuery, EscapeContextURLPath, EscapeContextJSONString, EscapeContextSQLLiteral:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidEscapeContext, c)
+	}
+}
+
+// escape escapes the value to be safely used in the context
+func (c EscapeContext) escape(value string) string {
+	switch c {
+	case EscapeContextURLQuery:
+		return url.QueryEscape(value)
+	case EscapeContextURLPath:
+		return url.PathEscape(value)
+	case EscapeContextJSONString:
+		return strings.TrimSuffix(strings.TrimPrefix(toJSON(value), `"`), `"`)
+	case EscapeContextSQLLiteral:
+		return strings.ReplaceAll(value, "'", "''")
+	default:
+		return value
+	}
+}
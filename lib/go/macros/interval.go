@@ -0,0 +1,115 @@
+package macros
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// defaultMaxDataPoints is used to calculate the interval when the max data points is not provided
+const defaultMaxDataPoints = 1500
+
+func intervalMsMacro(args []string, options Args) (string, error) {
+	return fmt.Sprintf("%d", calculateInterval(options.TimeRange, options.MaxDataPoints).Milliseconds()), nil
+}
+
+func intervalMacro(args []string, options Args) (string, error) {
+	return formatInterval(calculateInterval(options.TimeRange, options.MaxDataPoints)), nil
+}
+
+// calculateInterval returns the rounded interval between the data points for the given time range and max data points
+func calculateInterval(timeRange backend.TimeRange, maxDataPoints int64) time.Duration {
+	if maxDataPoints <= 0 {
+		maxDataPoints = defaultMaxDataPoints
+	}
+	return roundInterval(timeRange.Duration() / time.Duration(maxDataPoints))
+}
+
+// formatInterval formats the interval in Grafana's interval notation such as 30s, 5m or 1d
+func formatInterval(interval time.Duration) string {
+	ms := interval.Milliseconds()
+	switch {
+	case ms >= 31536000000 && ms%31536000000 == 0:
+		return fmt.Sprintf("%dy", ms/31536000000)
+	case ms >= 604800000 && ms%604800000 == 0:
+		return fmt.Sprintf("%dw", ms/604800000)
+	case ms >= 86400000 && ms%86400000 == 0:
+		return fmt.Sprintf("%dd", ms/86400000)
+	case ms >= 3600000 && ms%3600000 == 0:
+		return fmt.Sprintf("%dh", ms/3600000)
+	case ms >= 60000 && ms%60000 == 0:
+		return fmt.Sprintf("%dm", ms/60000)
+	case ms >= 1000 && ms%1000 == 0:
+		return fmt.Sprintf("%ds", ms/1000)
+	default:
+		return fmt.Sprintf("%dms", ms)
+	}
+}
+
+// roundInterval rounds the interval to a nice value. Same as the interval rounding in Grafana
+func roundInterval(interval time.Duration) time.Duration {
+	ms := interval.Milliseconds()
+	switch {
+	case ms <= 10:
+		return time.Millisecond
+	case ms <= 15:
+		return 10 * time.Millisecond
+	case ms <= 35:
+		return 20 * time.Millisecond
+	case ms <= 75:
+		return 50 * time.Millisecond
+	case ms <= 150:
+		return 100 * time.Millisecond
+	case ms <= 350:
+		return 200 * time.Millisecond
+	case ms <= 750:
+		return 500 * time.Millisecond
+	case ms <= 1500:
+		return time.Second
+	case ms <= 3500:
+		return 2 * time.Second
+	case ms <= 7500:
+		return 5 * time.Second
+	case ms <= 12500:
+		return 10 * time.Second
+	case ms <= 17500:
+		return 15 * time.Second
+	case ms <= 25000:
+		return 20 * time.Second
+	case ms <= 45000:
+		return 30 * time.Second
+	case ms <= 90000:
+		return time.Minute
+	case ms <= 210000:
+		return 2 * time.Minute
+	case ms <= 450000:
+		return 5 * time.Minute
+	case ms <= 750000:
+		return 10 * time.Minute
+	case ms <= 1050000:
+		return 15 * time.Minute
+	case ms <= 1500000:
+		return 20 * time.Minute
+	case ms <= 2700000:
+		return 30 * time.Minute
+	case ms <= 5400000:
+		return time.Hour
+	case ms <= 9000000:
+		return 2 * time.Hour
+	case ms <= 16200000:
+		return 3 * time.Hour
+	case ms <= 24300000:
+		return 6 * time.Hour
+	case ms <= 64800000:
+		return 12 * time.Hour
+	case ms <= 604800000:
+		return 24 * time.Hour
+	case ms <= 1814400000:
+		return 7 * 24 * time.Hour
+	case ms < 3628800000:
+		return 30 * 24 * time.Hour
+	default:
+		return 365 * 24 * time.Hour
+	}
+}
@@ -1,66 +1,114 @@
 package macros
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
 type Args struct {
+	// TimeRange of the query. Defaults to the time range of the Query
 	TimeRange backend.TimeRange
-	User      *backend.User
+	// User of the plugin context. Defaults to the user of the PluginContext
+	User *backend.User
+	// PluginContext is used for ${__org.id} and ${__datasource.*} macros
+	PluginContext *backend.PluginContext
+	// Query is used for ${__query.*} macros
+	Query *backend.DataQuery
+	// OrgName is used for ${__org.name} macro. Organization name is not available in the plugin context
+	OrgName string
+	// MaxDataPoints is used to calculate ${__interval} and ${__interval_ms}. Defaults to max data points of the Query or 1500
+	MaxDataPoints int64
+	// Now is the reference time for $__now and relative time expressions starting with now. Defaults to current time
+	Now time.Time
+	// Dialect renders the SQL macros such as $__timeFilter(column). SQL macros are not applied when the dialect is not set
+	Dialect Dialect
+	// Variables are the dashboard template variables keyed by the variable name. Variables are interpolated before the macros
+	Variables map[string]Variable
+	// Context is the target context of the input such as url query or json body. Defaults to raw.
+	// Output of the ${__name} and $__name macros and template variables are escaped to match the context. Use ${var:raw} to skip escaping.
+	// Output of the $__name(args) macros such as $__timeFilter(column) are query fragments and not escaped.
+	Context EscapeContext
+	// Timezone is the IANA timezone name such as `Australia/Sydney` used to format and round the time macros. Defaults to UTC.
+	// Use `:utc` suffix in the time macros to format in UTC. Example: ${__from:date:YYYY-MM-DD:utc}
+	Timezone string
+	// Page is used for $__page.offset, $__page.number, $__page.size and $__page.cursor macros. Set by the pagination driver
+	Page *Page
+	// Strict fails when unknown macros, malformed arguments or unbalanced parentheses are found
+	Strict bool
+	// location is the loaded timezone
+	location *time.Location
+}
+
+// timeLocation returns the location of the timezone. Defaults to UTC
+func (args Args) timeLocation() *time.Location {
+	if args.location == nil {
+		return time.UTC
+	}
+	return args.location
+}
+
+// loadLocation loads the location of the timezone
+func (args Args) loadLocation() (*time.Location, error) {
+	if args.Timezone == "" || strings.EqualFold(args.Timezone, "utc") {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(args.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimezone, args.Timezone)
+	}
+	return loc, nil
 }
 
 // ApplyMacros applies macros to the input string based on the provided query and plugin context.
+// Template variables are interpolated first using the $var, ${var} or ${var:format} syntax.
+// Supported formats are glob (default), raw, csv, json, regex, pipe, sqlstring, queryparam, singlequote, doublequote and percentencode.
+// ${var:default=value} is replaced with the value when the variable is not available or empty.
 // It replaces the following macros in the input string:
+//   - $__if(condition, text): Replaced with the text when the condition is not empty. Example: $__if(${region}, &region=${region})
 //   - ${__from}: Replaced with the start time of the query time range.
 //   - ${__timeFrom}: Replaced with the start time of the query time range. ( alias for from macro. also respect local timeShift defined in the panels )
 //   - ${__to}: Replaced with the end time of the query time range.
 //   - ${__timeTo}: Replaced with the end time of the query time range. ( alias for to macro. also respect local timeShift defined in the panels )
+//   - ${__from:-1h}, ${__to:now/d}: Time macros with relative time expression. Example: ${__from:now-7d/d:date:iso}
+//   - $__now, ${__now}: Replaced with the current time. Same as from, relative expressions and formats are supported.
+//   - $__interval, ${__interval}: Replaced with the interval calculated from the time range and max data points. Example: 30s
+//   - $__interval_ms, ${__interval_ms}: Replaced with the interval in milliseconds.
+//   - $__timeFilter(column), $__timeGroup(column, interval), $__unixEpochFilter(column), $__timeFrom(), $__timeTo(): SQL macros rendered using the dialect.
 //   - ${__user.name}: Replaced with the name of the plugin context user.
 //   - ${__user.email}: Replaced with the email of the plugin context user.
 //   - ${__user.login}: Replaced with the login of the plugin context user.
+//   - ${__user.role}: Replaced with the role of the plugin context user.
+//   - ${__org.id}, ${__org.name}: Replaced with the organization id of the plugin context and the organization name.
+//   - ${__datasource.uid}, ${__datasource.name}: Replaced with the uid and name of the plugin context datasource.
+//   - ${__query.refId}, ${__query.maxDataPoints}, ${__query.intervalMs}: Replaced with the ref id, max data points and interval of the query.
+//   - $__page.offset, $__page.number, $__page.size, $__page.cursor: Replaced with the current page state. See Paginate.
+//
+// To apply custom macros along with the built-in macros, register them to a registry created with NewDefaultRegistry and use Registry.Apply.
 //
 // Parameters:
 //   - input: The input string to apply macros to.
-//   - query: The data query containing the time range.
-//   - pluginCtx: The plugin context containing the user information.
+//   - args: The time range, user, plugin context, query and other arguments used by the macros.
 //
 // Returns:
 //   - The input string with macros replaced.
 //   - An error if there was an error applying the macros.
 func ApplyMacros(input string, args Args) (string, error) {
-	timeRange := args.TimeRange
-	var err error
-	timeMacros := []func(input string, timeRange backend.TimeRange) (string, error){
-		from,     // ${__from}
-		timeFrom, // ${__timeFrom}
-		to,       // ${__to}
-		timeTo,   // ${__timeTo}
-	}
-	for _, f := range timeMacros {
-		input, err = f(input, timeRange)
-		if err != nil {
-			return input, err
-		}
-	}
-	if args.User != nil {
-		input = strings.ReplaceAll(input, "${__user.name}", args.User.Name)
-		input = strings.ReplaceAll(input, "${__user.email}", args.User.Email)
-		input = strings.ReplaceAll(input, "${__user.login}", args.User.Login)
-	}
-	return input, nil
+	return defaultRegistry.Apply(input, args)
 }
 
-type macroFunc func(string, []string) (string, error)
+// defaultRegistry holds the built-in macros used by ApplyMacros
+var defaultRegistry = NewDefaultRegistry()
+
+// macroFunc expands the macro. match is the macro text found in the input and args are the macro arguments
+type macroFunc func(match string, args []string) (string, error)
 
 func getMatches(macroName, input string) ([][]string, error) {
-	macroRegex := fmt.Sprintf("\\$__%s\\b(?:\\((.*?)\\))?", macroName)
-	if strings.HasPrefix(macroName, "$$") { // prefix $$ is used to denote macro from frontend or grafana global variable
-		macroRegex = fmt.Sprintf("\\${__%s:?(.*?)}", strings.TrimPrefix(macroName, "$$"))
-	}
+	macroRegex := fmt.Sprintf("\\${__%s(?::(.*?))?}", regexp.QuoteMeta(macroName))
 	rgx, err := regexp.Compile(macroRegex)
 	if err != nil {
 		return nil, err
@@ -68,8 +116,13 @@ func getMatches(macroName, input string) ([][]string, error) {
 	return rgx.FindAllStringSubmatch(input, -1), nil
 }
 
+// applyMacro applies the macro to the query string. Macro key with prefix $$ denotes the ${__name:format} macro
+// ( macro from frontend or grafana global variable ) and the key without prefix denotes the $__name(args) macro
 func applyMacro(macroKey string, queryString string, macro macroFunc) (string, error) {
-	matches, err := getMatches(macroKey, queryString)
+	if !strings.HasPrefix(macroKey, "$$") {
+		return applyFunctionMacro(macroKey, queryString, macro)
+	}
+	matches, err := getMatches(strings.TrimPrefix(macroKey, "$$"), queryString)
 	if err != nil {
 		return queryString, err
 	}
@@ -81,7 +134,10 @@ func applyMacro(macroKey string, queryString string, macro macroFunc) (string, e
 		if len(match) > 1 {
 			args = strings.Split(match[1], ",")
 		}
-		res, err := macro(queryString, args)
+		res, err := macro(match[0], args)
+		if errors.Is(err, errMacroNotApplied) {
+			continue
+		}
 		if err != nil {
 			return queryString, err
 		}
@@ -89,3 +145,22 @@ func applyMacro(macroKey string, queryString string, macro macroFunc) (string, e
 	}
 	return strings.TrimSpace(queryString), nil
 }
+
+func applyFunctionMacro(macroName string, queryString string, macro macroFunc) (string, error) {
+	var sb strings.Builder
+	last := 0
+	for _, match := range findFunctionMacros(macroName, queryString) {
+		res, err := macro(queryString[match.start:match.end], match.args)
+		if errors.Is(err, errMacroNotApplied) {
+			continue
+		}
+		if err != nil {
+			return queryString, err
+		}
+		sb.WriteString(queryString[last:match.start])
+		sb.WriteString(res)
+		last = match.end
+	}
+	sb.WriteString(queryString[last:])
+	return strings.TrimSpace(sb.String()), nil
+}
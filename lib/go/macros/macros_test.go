@@ -1,10 +1,15 @@
 package macros_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/infinity-libs/lib/go/jsonframer"
 	"github.com/grafana/infinity-libs/lib/go/macros"
 	"github.com/stretchr/testify/require"
 )
@@ -46,3 +51,782 @@ func TestApplyMacros(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyMacrosWithRelativeTime(t *testing.T) {
+	from := time.Date(2020, 7, 13, 20, 19, 9, 254000000, time.UTC)
+	to := time.Date(2020, 7, 14, 20, 19, 9, 254000000, time.UTC)
+	now := time.Date(2020, 7, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		inputString   string
+		maxDataPoints int64
+		want          string
+		wantErr       error
+	}{
+		{inputString: "${__from:-1h}", want: "1594667949254"},
+		{inputString: "${__from:-1h:date:iso}", want: "2020-07-13T19:19:09.254Z"},
+		{inputString: "${__from:+1d-2h:date:iso}", want: "2020-07-14T18:19:09.254Z"},
+		{inputString: "${__from:/d:date:iso}", want: "2020-07-13T00:00:00Z"},
+		{inputString: "${__to:/d:date:iso}", want: "2020-07-14T23:59:59.999Z"},
+		{inputString: "${__from:now-7d/d:date:iso}", want: "2020-07-08T00:00:00Z"},
+		{inputString: "${__timeFrom:now/w:date:iso}", want: "2020-07-12T00:00:00Z"},
+		{inputString: "${__to:now/M:date:iso}", want: "2020-07-31T23:59:59.999Z"},
+		{inputString: "${__from:now-1y/y:date:YYYY-MM-DD}", want: "2019-01-01"},
+		{inputString: "$__now", want: "1594809000000"},
+		{inputString: "${__now}", want: "1594809000000"},
+		{inputString: "${__now:date:iso}", want: "2020-07-15T10:30:00Z"},
+		{inputString: "${__now:-30m:date:iso}", want: "2020-07-15T10:00:00Z"},
+		{inputString: "${__now:/h:date:seconds}", want: "1594807200"},
+		{inputString: "${__interval}", want: "1m"},
+		{inputString: "$__interval", want: "1m"},
+		{inputString: "${__interval_ms}", want: "60000"},
+		{inputString: "step=$__interval_ms&i=$__interval", want: "step=60000&i=1m"},
+		{inputString: "${__interval}", maxDataPoints: 24, want: "1h"},
+		{inputString: "${__interval}", maxDataPoints: 100000, want: "1s"},
+		{inputString: "${__from:-1x}", wantErr: macros.ErrInvalidRelativeTime},
+		{inputString: "${__from:now-}", wantErr: macros.ErrInvalidRelativeTime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.inputString, func(t *testing.T) {
+			got, err := macros.ApplyMacros(
+				tt.inputString,
+				macros.Args{
+					TimeRange:     backend.TimeRange{From: from, To: to},
+					MaxDataPoints: tt.maxDataPoints,
+					Now:           now,
+				},
+			)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseRelativeTime(t *testing.T) {
+	anchor := time.Date(2020, 7, 13, 20, 19, 9, 0, time.UTC)
+	now := time.Date(2020, 7, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr    string
+		roundUp bool
+		want    time.Time
+		wantErr bool
+	}{
+		{expr: "now", want: now},
+		{expr: "-5m", want: time.Date(2020, 7, 13, 20, 14, 9, 0, time.UTC)},
+		{expr: "now-1M", want: time.Date(2020, 6, 15, 10, 30, 0, 0, time.UTC)},
+		{expr: "now-2w/w", want: time.Date(2020, 6, 28, 0, 0, 0, 0, time.UTC)},
+		{expr: "now/d", roundUp: true, want: time.Date(2020, 7, 15, 23, 59, 59, 999000000, time.UTC)},
+		{expr: "/m", want: time.Date(2020, 7, 13, 20, 19, 0, 0, time.UTC)},
+		{expr: "", wantErr: true},
+		{expr: "yesterday", wantErr: true},
+		{expr: "now-1d/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := macros.ParseRelativeTime(tt.expr, anchor, now, tt.roundUp)
+			if tt.wantErr {
+				require.ErrorIs(t, err, macros.ErrInvalidRelativeTime)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyMacrosWithSQLDialect(t *testing.T) {
+	from := time.Date(2020, 7, 13, 20, 19, 9, 254000000, time.UTC)
+	to := time.Date(2020, 7, 14, 20, 19, 9, 0, time.UTC)
+	tests := []struct {
+		name        string
+		inputString string
+		dialect     macros.Dialect
+		want        string
+		wantErr     error
+	}{
+		{name: "no dialect", inputString: "SELECT * FROM t WHERE $__timeFilter(ts)", want: "SELECT * FROM t WHERE $__timeFilter(ts)"},
+		{name: "ansi time filter", inputString: "SELECT * FROM t WHERE $__timeFilter(ts)", dialect: macros.DialectANSI, want: "SELECT * FROM t WHERE ts BETWEEN TIMESTAMP '2020-07-13 20:19:09.254' AND TIMESTAMP '2020-07-14 20:19:09'"},
+		{name: "ansi time group", inputString: "SELECT $__timeGroup(ts, 5m)", dialect: macros.DialectANSI, want: "SELECT FLOOR(EXTRACT(EPOCH FROM ts) / 300) * 300"},
+		{name: "postgres time filter", inputString: "WHERE $__timeFilter(ts)", dialect: macros.DialectPostgres, want: "WHERE ts BETWEEN '2020-07-13T20:19:09.254Z' AND '2020-07-14T20:19:09Z'"},
+		{name: "postgres time group", inputString: "SELECT $__timeGroup(ts,1h)", dialect: macros.DialectPostgres, want: "SELECT floor(extract(epoch from ts)/3600)*3600"},
+		{name: "postgres time group with interval macro", inputString: "SELECT $__timeGroup(ts,$__interval)", dialect: macros.DialectPostgres, want: "SELECT floor(extract(epoch from ts)/60)*60"},
+		{name: "postgres time from and to", inputString: "$__timeFrom() - $__timeTo()", dialect: macros.DialectPostgres, want: "'2020-07-13T20:19:09.254Z' - '2020-07-14T20:19:09Z'"},
+		{name: "mysql time filter", inputString: "WHERE $__timeFilter(created_at)", dialect: macros.DialectMySQL, want: "WHERE created_at BETWEEN FROM_UNIXTIME(1594671549) AND FROM_UNIXTIME(1594757949)"},
+		{name: "mysql time group", inputString: "SELECT $__timeGroup(created_at, 1d)", dialect: macros.DialectMySQL, want: "SELECT UNIX_TIMESTAMP(created_at) DIV 86400 * 86400"},
+		{name: "mysql unix epoch filter", inputString: "WHERE $__unixEpochFilter(epoch)", dialect: macros.DialectMySQL, want: "WHERE epoch >= 1594671549 AND epoch <= 1594757949"},
+		{name: "clickhouse time filter", inputString: "WHERE $__timeFilter(ts)", dialect: macros.DialectClickHouse, want: "WHERE ts >= toDateTime(1594671549) AND ts <= toDateTime(1594757949)"},
+		{name: "clickhouse time group", inputString: "SELECT $__timeGroup(ts, 30s)", dialect: macros.DialectClickHouse, want: "SELECT toStartOfInterval(ts, INTERVAL 30 second)"},
+		{name: "time macros with braces are not sql macros", inputString: "${__timeFrom} $__timeFrom()", dialect: macros.DialectMySQL, want: "1594671549254 FROM_UNIXTIME(1594671549)"},
+		{name: "missing column", inputString: "WHERE $__timeFilter()", dialect: macros.DialectANSI, wantErr: macros.ErrInvalidMacroArguments},
+		{name: "missing interval", inputString: "SELECT $__timeGroup(ts)", dialect: macros.DialectANSI, wantErr: macros.ErrInvalidMacroArguments},
+		{name: "invalid interval", inputString: "SELECT $__timeGroup(ts, foo)", dialect: macros.DialectANSI, wantErr: macros.ErrInvalidMacroArguments},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := macros.ApplyMacros(tt.inputString, macros.Args{TimeRange: backend.TimeRange{From: from, To: to}, Dialect: tt.dialect})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	from := time.UnixMilli(1594671549254)
+	to := time.UnixMilli(1594757949254)
+	tenant := func(args []string, options macros.Args) (string, error) {
+		if len(args) > 0 && args[0] == "upper" {
+			return "ACME", nil
+		}
+		return "acme", nil
+	}
+	region := func(args []string, options macros.Args) (string, error) {
+		if len(args) != 1 || args[0] == "" {
+			return "", macros.ErrInvalidMacroArguments
+		}
+		return "region-" + args[0], nil
+	}
+	t.Run("custom macros should be applied with both syntaxes", func(t *testing.T) {
+		r := macros.NewDefaultRegistry()
+		require.Nil(t, r.Register("tenant", tenant))
+		require.Nil(t, r.Register("region", region))
+		got, err := r.Apply("/api/$__tenant/${__tenant:upper}/$__region(eu)?from=${__from}", macros.Args{TimeRange: backend.TimeRange{From: from, To: to}})
+		require.Nil(t, err)
+		require.Equal(t, "/api/acme/ACME/region-eu?from=1594671549254", got)
+	})
+	t.Run("custom macro errors should be returned", func(t *testing.T) {
+		r := macros.NewDefaultRegistry()
+		require.Nil(t, r.Register("region", region))
+		_, err := r.Apply("$__region()", macros.Args{})
+		require.ErrorIs(t, err, macros.ErrInvalidMacroArguments)
+	})
+	t.Run("custom macros should not be applied by ApplyMacros", func(t *testing.T) {
+		r := macros.NewDefaultRegistry()
+		require.Nil(t, r.Register("tenant", tenant))
+		got, err := macros.ApplyMacros("$__tenant", macros.Args{})
+		require.Nil(t, err)
+		require.Equal(t, "$__tenant", got)
+	})
+	t.Run("empty registry should not apply built-in macros", func(t *testing.T) {
+		r := macros.NewRegistry()
+		require.Nil(t, r.Register("tenant", tenant))
+		got, err := r.Apply("${__tenant} ${__from}", macros.Args{TimeRange: backend.TimeRange{From: from, To: to}})
+		require.Nil(t, err)
+		require.Equal(t, "acme ${__from}", got)
+	})
+	t.Run("registering existing macro should replace it", func(t *testing.T) {
+		r := macros.NewDefaultRegistry()
+		require.Nil(t, r.Register("from", tenant))
+		got, err := r.Apply("${__from} ${__timeFrom}", macros.Args{TimeRange: backend.TimeRange{From: from, To: to}})
+		require.Nil(t, err)
+		require.Equal(t, "acme 1594671549254", got)
+	})
+	t.Run("registering macro with syntax should only apply to the syntax", func(t *testing.T) {
+		r := macros.NewRegistry()
+		require.Nil(t, r.Register("tenant", tenant))
+		require.Nil(t, r.RegisterWithSyntax("tenant", macros.SyntaxFunction, region))
+		got, err := r.Apply("${__tenant} $__tenant(us)", macros.Args{})
+		require.Nil(t, err)
+		require.Equal(t, "acme region-us", got)
+		require.Equal(t, []string{"tenant"}, r.Names())
+	})
+	t.Run("macro names should not match the prefix of other macros", func(t *testing.T) {
+		r := macros.NewRegistry()
+		require.Nil(t, r.Register("user", tenant))
+		got, err := r.Apply("${__user} ${__user.name} $__user.name", macros.Args{})
+		require.Nil(t, err)
+		require.Equal(t, "acme ${__user.name} acme.name", got)
+	})
+	t.Run("unregistered macros should not be applied", func(t *testing.T) {
+		r := macros.NewDefaultRegistry()
+		r.Unregister("now")
+		require.NotContains(t, r.Names(), "now")
+		got, err := r.Apply("$__now", macros.Args{})
+		require.Nil(t, err)
+		require.Equal(t, "$__now", got)
+	})
+	t.Run("invalid macros should not be registered", func(t *testing.T) {
+		r := macros.NewRegistry()
+		require.ErrorIs(t, r.Register("", tenant), macros.ErrInvalidMacroName)
+		require.ErrorIs(t, r.Register("foo bar", tenant), macros.ErrInvalidMacroName)
+		require.ErrorIs(t, r.Register("foo", nil), macros.ErrInvalidMacro)
+	})
+	t.Run("user macros should be applied only when the user is available", func(t *testing.T) {
+		got, err := macros.ApplyMacros("${__user.login}", macros.Args{})
+		require.Nil(t, err)
+		require.Equal(t, "${__user.login}", got)
+		got, err = macros.ApplyMacros("${__user.login} ${__user.email}", macros.Args{User: &backend.User{Login: "foo", Email: "foo@bar.com"}})
+		require.Nil(t, err)
+		require.Equal(t, "foo foo@bar.com", got)
+	})
+}
+
+func TestApplyMacrosWithVariables(t *testing.T) {
+	variables := map[string]macros.Variable{
+		"region":     macros.SingleValueVariable("us-east"),
+		"regionName": macros.SingleValueVariable("US East"),
+		"hosts":      macros.MultiValueVariable("a.com", "b's.com"),
+		"single":     macros.MultiValueVariable("foo"),
+		"quote":      macros.SingleValueVariable(`it's "x" & y`),
+		"interval":   macros.SingleValueVariable("5m"),
+	}
+	tests := []struct {
+		inputString string
+		want        string
+	}{
+		{inputString: "$region", want: "us-east"},
+		{inputString: "${region}", want: "us-east"},
+		{inputString: "$regionName-$region", want: "US East-us-east"},
+		{inputString: "$regions", want: "$regions"},
+		{inputString: "${unknown} $unknown", want: "${unknown} $unknown"},
+		{inputString: "${hosts}", want: "{a.com,b's.com}"},
+		{inputString: "${hosts:glob}", want: "{a.com,b's.com}"},
+		{inputString: "${hosts:foo}", want: "{a.com,b's.com}"},
+		{inputString: "${hosts:raw}", want: "a.com,b's.com"},
+		{inputString: "${hosts:csv}", want: "a.com,b's.com"},
+		{inputString: "${hosts:json}", want: `["a.com","b's.com"]`},
+		{inputString: "${hosts:regex}", want: `(a\.com|b's\.com)`},
+		{inputString: "${hosts:pipe}", want: "a.com|b's.com"},
+		{inputString: "${hosts:sqlstring}", want: "'a.com','b''s.com'"},
+		{inputString: "${hosts:singlequote}", want: `'a.com','b\'s.com'`},
+		{inputString: "${hosts:doublequote}", want: `"a.com","b's.com"`},
+		{inputString: "${hosts:queryparam}", want: "var-hosts=a.com&var-hosts=b%27s.com"},
+		{inputString: "${hosts:percentencode}", want: "%7Ba.com%2Cb%27s.com%7D"},
+		{inputString: "${single}", want: "foo"},
+		{inputString: "${single:regex}", want: "foo"},
+		{inputString: "${single:json}", want: `["foo"]`},
+		{inputString: "${region:json}", want: `"us-east"`},
+		{inputString: "${region:regex}", want: "us-east"},
+		{inputString: "${region:queryparam}", want: "var-region=us-east"},
+		{inputString: "${quote:sqlstring}", want: `'it''s "x" & y'`},
+		{inputString: "${quote:percentencode}", want: "it%27s%20%22x%22%20%26%20y"},
+		{inputString: "${quote:json}", want: `"it's \"x\" & y"`},
+		{inputString: "${regionName:queryparam}", want: "var-regionName=US%20East"},
+		{inputString: "/api/${region}/users?from=${__from}", want: "/api/us-east/users?from=1594671549254"},
+		{inputString: "$__timeGroup(ts, $interval)", want: "floor(extract(epoch from ts)/300)*300"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.inputString, func(t *testing.T) {
+			got, err := macros.ApplyMacros(tt.inputString, macros.Args{
+				TimeRange: backend.TimeRange{From: time.UnixMilli(1594671549254), To: time.UnixMilli(1594757949254)},
+				Variables: variables,
+				Dialect:   macros.DialectPostgres,
+			})
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyMacrosWithEscapeContext(t *testing.T) {
+	user := &backend.User{Name: `O'Neil & "Sons"/Co`, Login: "foo bar"}
+	variables := map[string]macros.Variable{
+		"name":  macros.SingleValueVariable("a&b c"),
+		"hosts": macros.MultiValueVariable("a", "b"),
+	}
+	tests := []struct {
+		name        string
+		inputString string
+		context     macros.EscapeContext
+		want        string
+		wantErr     error
+	}{
+		{name: "default", inputString: "${__user.name}", want: `O'Neil & "Sons"/Co`},
+		{name: "raw", inputString: "${__user.name}", context: macros.EscapeContextRaw, want: `O'Neil & "Sons"/Co`},
+		{name: "url query", inputString: "https://foo.com?user=${__user.name}&login=${__user.login}", context: macros.EscapeContextURLQuery, want: "https://foo.com?user=O%27Neil+%26+%22Sons%22%2FCo&login=foo+bar"},
+		{name: "url path", inputString: "https://foo.com/users/${__user.name}", context: macros.EscapeContextURLPath, want: "https://foo.com/users/O%27Neil%20&%20%22Sons%22%2FCo"},
+		{name: "json string", inputString: `{"user":"${__user.name}"}`, context: macros.EscapeContextJSONString, want: `{"user":"O'Neil & \"Sons\"/Co"}`},
+		{name: "sql literal", inputString: "SELECT * FROM t WHERE user = '${__user.name}'", context: macros.EscapeContextSQLLiteral, want: `SELECT * FROM t WHERE user = 'O''Neil & "Sons"/Co'`},
+		{name: "time macros in url query", inputString: "from=${__from:date:iso}", context: macros.EscapeContextURLQuery, want: "from=2020-07-13T20%3A19%3A09.254Z"},
+		{name: "function macros are not escaped", inputString: "SELECT * FROM t WHERE $__timeFilter(ts)", context: macros.EscapeContextSQLLiteral, want: "SELECT * FROM t WHERE ts BETWEEN '2020-07-13T20:19:09.254Z' AND '2020-07-14T20:19:09.254Z'"},
+		{name: "variables without format are escaped", inputString: "q=${name}&r=$name", context: macros.EscapeContextURLQuery, want: "q=a%26b+c&r=a%26b+c"},
+		{name: "variables with format are escaped", inputString: "h=${hosts:csv}&n=${name:csv}&p=${hosts:pipe}", context: macros.EscapeContextURLQuery, want: "h=a%2Cb&n=a%26b+c&p=a%7Cb"},
+		{name: "variables with format in json string", inputString: `{"h":"${hosts:doublequote}"}`, context: macros.EscapeContextJSONString, want: `{"h":"\"a\",\"b\""}`},
+		{name: "variables with raw format are not escaped", inputString: "n=${name:raw}", context: macros.EscapeContextURLQuery, want: "n=a&b c"},
+		{name: "variables with url encoded format are not escaped again", inputString: "${hosts:queryparam}&n=${name:percentencode}", context: macros.EscapeContextURLQuery, want: "var-hosts=a&var-hosts=b&n=a%26b%20c"},
+		{name: "invalid context", inputString: "${__user.name}", context: "xml", wantErr: macros.ErrInvalidEscapeContext},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := macros.ApplyMacros(tt.inputString, macros.Args{
+				TimeRange: backend.TimeRange{From: time.UnixMilli(1594671549254), To: time.UnixMilli(1594757949254)},
+				User:      user,
+				Variables: variables,
+				Dialect:   macros.DialectPostgres,
+				Context:   tt.context,
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyMacrosWithTimezone(t *testing.T) {
+	// 2020-07-13T20:19:09.254Z is 2020-07-14 06:19:09 in Australia/Sydney (UTC+10)
+	from := time.UnixMilli(1594671549254)
+	to := time.UnixMilli(1594757949254)
+	now := time.Date(2020, 7, 15, 20, 30, 0, 0, time.UTC)
+	tests := []struct {
+		inputString string
+		timezone    string
+		want        string
+		wantErr     error
+	}{
+		{inputString: "${__from:date:YYYY-MM-DD}", want: "2020-07-13"},
+		{inputString: "${__from:date:YYYY-MM-DD}", timezone: "utc", want: "2020-07-13"},
+		{inputString: "${__from:date:YYYY-MM-DD}", timezone: "Australia/Sydney", want: "2020-07-14"},
+		{inputString: "${__from:date:YYYY-MM-DD HH:mm}", timezone: "Australia/Sydney", want: "2020-07-14 06:19"},
+		{inputString: "${__from:date:YYYY-MM-DD:utc}", timezone: "Australia/Sydney", want: "2020-07-13"},
+		{inputString: "${__to:date:YYYY-MM-DD}", timezone: "America/New_York", want: "2020-07-14"},
+		{inputString: "${__from}", timezone: "Australia/Sydney", want: "1594671549254"},
+		{inputString: "${__from:utc}", timezone: "Australia/Sydney", want: "1594671549254"},
+		{inputString: "${__from:date:seconds}", timezone: "Australia/Sydney", want: "1594671549"},
+		{inputString: "${__from:date:iso}", timezone: "Australia/Sydney", want: "2020-07-13T20:19:09.254Z"},
+		{inputString: "${__from:date}", timezone: "Australia/Sydney", want: "2020-07-13T20:19:09.254Z"},
+		{inputString: "${__from:/d:date:iso}", timezone: "Australia/Sydney", want: "2020-07-13T14:00:00Z"},
+		{inputString: "${__from:/d:date:iso:utc}", timezone: "Australia/Sydney", want: "2020-07-13T00:00:00Z"},
+		{inputString: "${__now:now/d:date:YYYY-MM-DD HH:mm}", timezone: "Australia/Sydney", want: "2020-07-16 00:00"},
+		{inputString: "${__from:date}", timezone: "Mars/Olympus", wantErr: macros.ErrInvalidTimezone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timezone+" "+tt.inputString, func(t *testing.T) {
+			got, err := macros.ApplyMacros(tt.inputString, macros.Args{
+				TimeRange: backend.TimeRange{From: from, To: to},
+				Now:       now,
+				Timezone:  tt.timezone,
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyMacrosWithMomentFormat(t *testing.T) {
+	from := time.Date(2020, 7, 13, 20, 19, 9, 254000000, time.UTC) // Monday
+	to := time.Date(2021, 1, 1, 0, 5, 0, 0, time.UTC)              // Friday
+	tests := []struct {
+		inputString string
+		want        string
+	}{
+		{inputString: "${__from:date:YYYY YY Y}", want: "2020 20 2020"},
+		{inputString: "${__from:date:Q Qo}", want: "3 3rd"},
+		{inputString: "${__from:date:MMMM MMM MM Mo M}", want: "July Jul 07 7th 7"},
+		{inputString: "${__from:date:DDDD DDDo DDD}", want: "195 195th 195"},
+		{inputString: "${__to:date:DDDD DDD}", want: "001 1"},
+		{inputString: "${__from:date:DD Do D}", want: "13 13th 13"},
+		{inputString: "${__from:date:dddd ddd dd do d e E}", want: "Monday Mon Mo 1st 1 1 1"},
+		{inputString: "${__from:date:GGGG GG WW Wo W}", want: "2020 20 29 29th 29"},
+		{inputString: "${__to:date:GGGG-[W]WW}", want: "2020-W53"},
+		{inputString: "${__from:date:gggg gg ww wo w}", want: "2020 20 29 29th 29"},
+		{inputString: "${__to:date:gggg-ww}", want: "2021-01"},
+		{inputString: "${__from:date:HH H hh h kk k}", want: "20 20 08 8 20 20"},
+		{inputString: "${__to:date:HH H hh h kk k}", want: "00 0 12 12 24 24"},
+		{inputString: "${__from:date:mm m ss s}", want: "19 19 09 9"},
+		{inputString: "${__to:date:mm m ss s}", want: "05 5 00 0"},
+		{inputString: "${__from:date:S SS SSS SSSSSS}", want: "2 25 254 254000"},
+		{inputString: "${__from:date:hh:mm A a}", want: "08:19 PM pm"},
+		{inputString: "${__to:date:hh:mm A a}", want: "12:05 AM am"},
+		{inputString: "${__from:date:Z ZZ z}", want: "+00:00 +0000 UTC"},
+		{inputString: "${__from:date:X x}", want: "1594671549 1594671549254"},
+		{inputString: "${__from:date:YYYY-MM-DD [at] HH:mm}", want: "2020-07-13 at 20:19"},
+		{inputString: "${__from:date:[Today is] dddd}", want: "Today is Monday"},
+		{inputString: "${__from:date:[YYYY] YYYY [}", want: "YYYY 2020 ["},
+		{inputString: "${__from:date:YYYY-MM-DDTHH:mm:ss.SSSZ}", want: "2020-07-13T20:19:09.254+00:00"},
+		{inputString: "${__from:date:MMM Do, YYYY}", want: "Jul 13th, 2020"},
+		{inputString: "${__from:date:Qo [quarter]}", want: "3rd quarter"},
+		{inputString: "${__from:date:Do}-${__to:date:Do}", want: "13th-1st"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.inputString, func(t *testing.T) {
+			got, err := macros.ApplyMacros(tt.inputString, macros.Args{TimeRange: backend.TimeRange{From: from, To: to}})
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyMacrosWithDiagnostics(t *testing.T) {
+	from := time.UnixMilli(1594671549254)
+	to := time.UnixMilli(1594757949254)
+	tests := []struct {
+		name            string
+		inputString     string
+		strict          bool
+		want            string
+		wantDiagnostics []macros.Diagnostic
+		wantErr         error
+	}{
+		{name: "valid macros", inputString: "from=${__from}&to=$__to", want: "from=1594671549254&to=1594757949254", wantDiagnostics: []macros.Diagnostic{}},
+		{
+			name:            "unknown variable macro",
+			inputString:     "from=${__form}&to=${__to}",
+			want:            "from=${__form}&to=1594757949254",
+			wantDiagnostics: []macros.Diagnostic{{Macro: "${__form}", Offset: 5, Kind: macros.DiagnosticKindUnknownMacro}},
+		},
+		{
+			name:            "unknown function macro",
+			inputString:     "SELECT $__timeFiltr(ts)",
+			want:            "SELECT $__timeFiltr(ts)",
+			wantDiagnostics: []macros.Diagnostic{{Macro: "$__timeFiltr", Offset: 7, Kind: macros.DiagnosticKindUnknownMacro}},
+		},
+		{
+			name:            "macro registered for other syntax",
+			inputString:     "$__user.name",
+			want:            "$__user.name",
+			wantDiagnostics: []macros.Diagnostic{{Macro: "$__user.name", Offset: 0, Kind: macros.DiagnosticKindUnknownMacro}},
+		},
+		{
+			name:            "unbalanced parentheses",
+			inputString:     "SELECT * FROM t WHERE $__timeFilter(ts",
+			want:            "SELECT * FROM t WHERE $__timeFilter(ts",
+			wantDiagnostics: []macros.Diagnostic{{Macro: "$__timeFilter", Offset: 22, Kind: macros.DiagnosticKindUnbalancedParentheses, Message: "missing closing parenthesis"}},
+		},
+		{
+			name:            "unbalanced braces",
+			inputString:     "x ${__from",
+			want:            "x ${__from",
+			wantDiagnostics: []macros.Diagnostic{{Macro: "${__from}", Offset: 2, Kind: macros.DiagnosticKindUnbalancedBraces, Message: "missing closing brace"}},
+		},
+		{
+			name:        "bad arguments",
+			inputString: "SELECT $__timeGroup(ts), $__timeFilter(ts)",
+			want:        "SELECT $__timeGroup(ts), ts BETWEEN '2020-07-13T20:19:09.254Z' AND '2020-07-14T20:19:09.254Z'",
+			wantDiagnostics: []macros.Diagnostic{{
+				Macro:   "$__timeGroup(ts)",
+				Offset:  7,
+				Kind:    macros.DiagnosticKindBadArguments,
+				Message: "invalid macro arguments: $__timeGroup expects column and interval arguments",
+			}},
+		},
+		{
+			name:        "bad arguments of repeated macro",
+			inputString: "$__timeGroup(ts, 1h) $__timeGroup(ts)",
+			want:        `floor(extract(epoch from ts)/3600)*3600 $__timeGroup(ts)`,
+			wantDiagnostics: []macros.Diagnostic{{
+				Macro:   "$__timeGroup(ts)",
+				Offset:  21,
+				Kind:    macros.DiagnosticKindBadArguments,
+				Message: "invalid macro arguments: $__timeGroup expects column and interval arguments",
+			}},
+		},
+		{
+			name:            "bad relative time",
+			inputString:     "${__from:-1x}",
+			want:            "${__from:-1x}",
+			wantDiagnostics: []macros.Diagnostic{{Macro: "${__from:-1x}", Offset: 0, Kind: macros.DiagnosticKindBadArguments, Message: `invalid relative time expression: "-1x"`}},
+		},
+		{
+			name:            "multiple diagnostics are sorted by offset",
+			inputString:     "${__to} $__foo ${__bar}",
+			want:            "1594757949254 $__foo ${__bar}",
+			wantDiagnostics: []macros.Diagnostic{{Macro: "$__foo", Offset: 8, Kind: macros.DiagnosticKindUnknownMacro}, {Macro: "${__bar}", Offset: 15, Kind: macros.DiagnosticKindUnknownMacro}},
+		},
+		{
+			name:            "strict mode should fail",
+			inputString:     "from=${__form}",
+			strict:          true,
+			want:            "from=${__form}",
+			wantDiagnostics: []macros.Diagnostic{{Macro: "${__form}", Offset: 5, Kind: macros.DiagnosticKindUnknownMacro}},
+			wantErr:         macros.ErrMacroDiagnostics,
+		},
+		{
+			name:            "strict mode should fail on bad arguments",
+			inputString:     "$__timeFilter()",
+			strict:          true,
+			want:            "$__timeFilter()",
+			wantDiagnostics: []macros.Diagnostic{{Macro: "$__timeFilter()", Offset: 0, Kind: macros.DiagnosticKindBadArguments, Message: "invalid macro arguments: $__timeFilter expects column argument"}},
+			wantErr:         macros.ErrMacroDiagnostics,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := macros.Args{TimeRange: backend.TimeRange{From: from, To: to}, Dialect: macros.DialectPostgres, Strict: tt.strict}
+			got, diagnostics, err := macros.ApplyMacrosWithDiagnostics(tt.inputString, args)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.Nil(t, err)
+			}
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantDiagnostics, diagnostics)
+		})
+	}
+	t.Run("strict mode should fail with ApplyMacros", func(t *testing.T) {
+		_, err := macros.ApplyMacros("${__form}", macros.Args{Strict: true})
+		require.ErrorIs(t, err, macros.ErrMacroDiagnostics)
+		require.Equal(t, "invalid macros found\nunknown-macro \"${__form}\" at offset 0", err.Error())
+		var diagnostic macros.Diagnostic
+		require.ErrorAs(t, err, &diagnostic)
+		require.Equal(t, 0, diagnostic.Offset)
+	})
+	t.Run("unknown macros should be ignored with ApplyMacros", func(t *testing.T) {
+		got, err := macros.ApplyMacros("${__form}", macros.Args{})
+		require.Nil(t, err)
+		require.Equal(t, "${__form}", got)
+	})
+}
+
+func TestApplyMacrosWithNestedArguments(t *testing.T) {
+	from := time.UnixMilli(1594671549254)
+	to := time.UnixMilli(1594757949254)
+	join := func(args []string, options macros.Args) (string, error) {
+		return "[" + strings.Join(args, "|") + "]", nil
+	}
+	upper := func(args []string, options macros.Args) (string, error) {
+		return strings.ToUpper(strings.Join(args, "")), nil
+	}
+	r := macros.NewDefaultRegistry()
+	require.Nil(t, r.Register("fn", join))
+	require.Nil(t, r.Register("upper", upper))
+	tests := []struct {
+		inputString string
+		want        string
+	}{
+		{inputString: "$__fn(a, b ,c)", want: "[a|b|c]"},
+		{inputString: "$__fn()", want: "[]"},
+		{inputString: "$__fn", want: "[]"},
+		{inputString: "$__fn(concat(a,b), 'x,y')", want: "[concat(a,b)|'x,y']"},
+		{inputString: `$__fn("a)b", 'it\'s,ok', x)`, want: `["a)b"|'it\'s,ok'|x]`},
+		{inputString: "$__fn(`a,b`, [1,2], {\"k\":1,\"v\":2})", want: "[`a,b`|[1,2]|{\"k\":1,\"v\":2}]"},
+		{inputString: "$__fn(f(g(h(1,2),3)),4) and $__fn(5)", want: "[f(g(h(1,2),3))|4] and [5]"},
+		{inputString: "$__fn($__upper(abc), d)", want: "[ABC|d]"},
+		{inputString: "$__fn($__fn(a,b), c)", want: "[[a|b]|c]"},
+		{inputString: "$__upper($__fn(x, ${__from}))", want: "[X|1594671549254]"},
+		{inputString: "$__fn(a, b", want: "[](a, b"},
+		{inputString: "$__fnx(a)", want: "$__fnx(a)"},
+		{inputString: "$__timeFilter(coalesce(ts, created_at))", want: "coalesce(ts, created_at) BETWEEN '2020-07-13T20:19:09.254Z' AND '2020-07-14T20:19:09.254Z'"},
+		{inputString: "$__timeGroup(date_trunc('day', ts), 1h)", want: "floor(extract(epoch from date_trunc('day', ts))/3600)*3600"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.inputString, func(t *testing.T) {
+			got, err := r.Apply(tt.inputString, macros.Args{TimeRange: backend.TimeRange{From: from, To: to}, Dialect: macros.DialectPostgres})
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyMacrosWithPluginContextAndQuery(t *testing.T) {
+	from := time.UnixMilli(1594671549254)
+	to := time.UnixMilli(1594757949254)
+	pluginCtx := &backend.PluginContext{
+		OrgID:                      3,
+		User:                       &backend.User{Login: "foo", Role: "Editor"},
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds-uid", Name: "My API"},
+	}
+	query := &backend.DataQuery{RefID: "A", MaxDataPoints: 24, Interval: 30 * time.Second, TimeRange: backend.TimeRange{From: from, To: to}}
+	tests := []struct {
+		name        string
+		inputString string
+		args        macros.Args
+		want        string
+	}{
+		{name: "org", inputString: "/orgs/${__org.id}/${__org.name}", args: macros.Args{PluginContext: pluginCtx, OrgName: "Main"}, want: "/orgs/3/Main"},
+		{name: "datasource", inputString: "${__datasource.uid} ${__datasource.name}", args: macros.Args{PluginContext: pluginCtx}, want: "ds-uid My API"},
+		{name: "user from plugin context", inputString: "${__user.login} ${__user.role}", args: macros.Args{PluginContext: pluginCtx}, want: "foo Editor"},
+		{name: "user overrides plugin context", inputString: "${__user.login}", args: macros.Args{PluginContext: pluginCtx, User: &backend.User{Login: "bar"}}, want: "bar"},
+		{name: "query", inputString: "ref=${__query.refId}&points=${__query.maxDataPoints}&interval=${__query.intervalMs}", args: macros.Args{Query: query}, want: "ref=A&points=24&interval=30000"},
+		{name: "time range and max data points from query", inputString: "${__from} ${__to} ${__interval}", args: macros.Args{Query: query}, want: "1594671549254 1594757949254 1h"},
+		{name: "time range overrides query", inputString: "${__from}", args: macros.Args{Query: query, TimeRange: backend.TimeRange{From: to, To: to}}, want: "1594757949254"},
+		{name: "missing context", inputString: "${__org.id} ${__org.name} ${__datasource.uid} ${__query.refId} ${__user.role}", want: "${__org.id} ${__org.name} ${__datasource.uid} ${__query.refId} ${__user.role}"},
+		{name: "missing datasource", inputString: "${__datasource.uid}", args: macros.Args{PluginContext: &backend.PluginContext{OrgID: 1}}, want: "${__datasource.uid}"},
+		{name: "escaped for url", inputString: "?ds=${__datasource.name}", args: macros.Args{PluginContext: pluginCtx, Context: macros.EscapeContextURLQuery}, want: "?ds=My+API"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := macros.ApplyMacros(tt.inputString, tt.args)
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyMacrosWithConditionalBlocks(t *testing.T) {
+	from := time.UnixMilli(1594671549254)
+	to := time.UnixMilli(1594757949254)
+	variables := map[string]macros.Variable{
+		"region": macros.SingleValueVariable("us east"),
+		"empty":  macros.SingleValueVariable(""),
+		"none":   macros.MultiValueVariable(),
+		"hosts":  macros.MultiValueVariable("a", "b"),
+	}
+	tests := []struct {
+		name        string
+		inputString string
+		context     macros.EscapeContext
+		want        string
+		wantErr     error
+	}{
+		{name: "variable set", inputString: "/users?limit=10$__if(${region}, &region=${region})", want: "/users?limit=10&region=us east"},
+		{name: "variable set with url escaping", inputString: "/users?limit=10$__if(${region}, &region=${region})", context: macros.EscapeContextURLQuery, want: "/users?limit=10&region=us+east"},
+		{name: "variable empty", inputString: "/users?limit=10$__if(${empty}, &region=${empty})", want: "/users?limit=10"},
+		{name: "variable without values", inputString: "/users?limit=10$__if($none, &region=$none)", want: "/users?limit=10"},
+		{name: "variable not available", inputString: "/users?limit=10$__if(${unknown}, &region=${unknown})", want: "/users?limit=10"},
+		{name: "variable not available with format", inputString: "/users$__if(${unknown:csv}, ?r=${unknown:csv})", want: "/users"},
+		{name: "text with commas", inputString: "/users?limit=10$__if(${hosts}, &hosts=${hosts:csv})", want: "/users?limit=10&hosts=a,b"},
+		{name: "text with spaces after commas", inputString: "SELECT * FROM t WHERE x=1$__if(${region}, AND region IN ('a', 'b'), LIMIT 10)", want: "SELECT * FROM t WHERE x=1AND region IN ('a', 'b'), LIMIT 10"},
+		{name: "text with macros", inputString: "/users$__if(${region}, ?from=${__from})", want: "/users?from=1594671549254"},
+		{name: "multiple conditions", inputString: "/users?x=1$__if(${region},&region=${region})$__if(${empty},&empty=${empty})", want: "/users?x=1&region=us east"},
+		{name: "missing text", inputString: "$__if(${region})", wantErr: macros.ErrInvalidMacroArguments},
+		{name: "default value with variable set", inputString: "region=${region:default=all}", want: "region=us east"},
+		{name: "default value with empty variable", inputString: "region=${empty:default=all}", want: "region=all"},
+		{name: "default value with variable without values", inputString: "region=${none:default=all}", want: "region=all"},
+		{name: "default value with variable not available", inputString: "region=${unknown:default=all}&x=${unknown2:default=}", want: "region=all&x="},
+		{name: "default value with multi value variable", inputString: "${hosts:default=all}", want: "{a,b}"},
+		{name: "default value with escaping", inputString: "region=${region:default=all}", context: macros.EscapeContextURLQuery, want: "region=us+east"},
+		{name: "default value inside condition", inputString: "/users?region=${unknown:default=all}$__if(${region}, &r=1)", want: "/users?region=all&r=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := macros.ApplyMacros(tt.inputString, macros.Args{
+				TimeRange: backend.TimeRange{From: from, To: to},
+				Variables: variables,
+				Context:   tt.context,
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	// pages splits the users with ids 1 to 5 into pages of the given size
+	pages := func(pageSize int) []string {
+		out := []string{}
+		for start := 1; start <= 5; start += pageSize {
+			users := []string{}
+			for id := start; id < start+pageSize && id <= 5; id++ {
+				users = append(users, fmt.Sprintf(`{"id":%d}`, id))
+			}
+			out = append(out, "["+strings.Join(users, ",")+"]")
+		}
+		return out
+	}
+	tests := []struct {
+		name         string
+		input        string
+		context      macros.EscapeContext
+		options      macros.PaginationOptions
+		responses    map[string]string
+		wantRequests []string
+		wantRows     int
+		wantNotice   bool
+		wantErr      error
+	}{
+		{
+			name:         "offset pagination stops when the page has fewer rows",
+			input:        "/users?offset=$__page.offset&limit=${__page.size}",
+			options:      macros.PaginationOptions{PageSize: 2},
+			responses:    map[string]string{"/users?offset=0&limit=2": pages(2)[0], "/users?offset=2&limit=2": pages(2)[1], "/users?offset=4&limit=2": pages(2)[2]},
+			wantRequests: []string{"/users?offset=0&limit=2", "/users?offset=2&limit=2", "/users?offset=4&limit=2"},
+			wantRows:     5,
+		},
+		{
+			name:         "page number pagination stops when the page is empty",
+			input:        "/users?page=$__page.number",
+			options:      macros.PaginationOptions{StartPage: 0},
+			responses:    map[string]string{"/users?page=1": pages(3)[0], "/users?page=2": pages(3)[1], "/users?page=3": `[]`},
+			wantRequests: []string{"/users?page=1", "/users?page=2", "/users?page=3"},
+			wantRows:     5,
+		},
+		{
+			name:         "max pages",
+			input:        "/users?page=$__page.number",
+			options:      macros.PaginationOptions{MaxPages: 2},
+			responses:    map[string]string{"/users?page=1": pages(2)[0], "/users?page=2": pages(2)[1], "/users?page=3": pages(2)[2]},
+			wantRequests: []string{"/users?page=1", "/users?page=2"},
+			wantRows:     4,
+			wantNotice:   true,
+		},
+		{
+			name:    "cursor pagination",
+			input:   "/users?cursor=$__page.cursor",
+			context: macros.EscapeContextURLQuery,
+			options: macros.PaginationOptions{NextCursorSelector: "next", FramerOptions: jsonframer.FramerOptions{RootSelector: "items"}},
+			responses: map[string]string{
+				"/users?cursor=":        `{"items":[{"id":1},{"id":2}],"next":"a b"}`,
+				"/users?cursor=a+b":     `{"items":[{"id":3},{"id":4}],"next":"c&d"}`,
+				"/users?cursor=c%26d":   `{"items":[{"id":5}],"next":null}`,
+				"/users?cursor=unknown": `{"items":[]}`,
+			},
+			wantRequests: []string{"/users?cursor=", "/users?cursor=a+b", "/users?cursor=c%26d"},
+			wantRows:     5,
+		},
+		{
+			name:         "cursor pagination stops when the cursor repeats",
+			input:        "/users?cursor=$__page.cursor",
+			options:      macros.PaginationOptions{NextCursorSelector: "next", FramerOptions: jsonframer.FramerOptions{RootSelector: "items"}},
+			responses:    map[string]string{"/users?cursor=": `{"items":[{"id":1},{"id":2}],"next":"a"}`, "/users?cursor=a": `{"items":[{"id":3},{"id":4}],"next":"a"}`},
+			wantRequests: []string{"/users?cursor=", "/users?cursor=a"},
+			wantRows:     4,
+		},
+		{
+			name:    "cursor pagination stops when the page has fewer rows",
+			input:   "/users?cursor=$__page.cursor&limit=$__page.size",
+			options: macros.PaginationOptions{PageSize: 2, NextCursorSelector: "next", FramerOptions: jsonframer.FramerOptions{RootSelector: "items"}},
+			responses: map[string]string{
+				"/users?cursor=&limit=2":  `{"items":[{"id":1},{"id":2}],"next":"a"}`,
+				"/users?cursor=a&limit=2": `{"items":[{"id":3}],"next":"b"}`,
+				"/users?cursor=b&limit=2": `{"items":[{"id":4},{"id":5}],"next":"c"}`,
+			},
+			wantRequests: []string{"/users?cursor=&limit=2", "/users?cursor=a&limit=2"},
+			wantRows:     3,
+		},
+		{
+			name:         "fetch error",
+			input:        "/users?page=$__page.number",
+			responses:    map[string]string{"/users?page=1": pages(2)[0]},
+			wantRequests: []string{"/users?page=1", "/users?page=2"},
+			wantErr:      macros.ErrFetchingPage,
+		},
+		{
+			name:    "invalid options",
+			input:   "/users?page=$__page.number",
+			options: macros.PaginationOptions{PageSize: -1},
+			wantErr: macros.ErrInvalidPaginationOptions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			frame, err := macros.Paginate(context.Background(), tt.input, macros.Args{Context: tt.context}, tt.options, func(ctx context.Context, request string) (string, error) {
+				requests = append(requests, request)
+				if response, ok := tt.responses[request]; ok {
+					return response, nil
+				}
+				return "", errors.New("page not found")
+			})
+			require.Equal(t, tt.wantRequests, requests)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantRows, frame.Rows())
+			require.Equal(t, tt.wantNotice, frame.Meta != nil && len(frame.Meta.Notices) > 0)
+		})
+	}
+	t.Run("page macros are not applied without page", func(t *testing.T) {
+		got, err := macros.ApplyMacros("/users?offset=$__page.offset&cursor=${__page.cursor}", macros.Args{})
+		require.Nil(t, err)
+		require.Equal(t, "/users?offset=$__page.offset&cursor=${__page.cursor}", got)
+	})
+}
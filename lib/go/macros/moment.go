@@ -0,0 +1,155 @@
+package macros
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type momentToken struct {
+	token  string
+	format func(t time.Time) string
+}
+
+// momentTokens are the supported moment.js format tokens. Tokens sharing the same prefix are ordered longest first.
+// https://momentjs.com/docs/#/displaying/format/
+var momentTokens = []momentToken{
+	{"YYYY", func(t time.Time) string { return fmt.Sprintf("%04d", t.Year()) }},
+	{"YY", func(t time.Time) string { return fmt.Sprintf("%02d", t.Year()%100) }},
+	{"Y", func(t time.Time) string { return fmt.Sprintf("%d", t.Year()) }},
+	{"Qo", func(t time.Time) string { return ordinal(quarter(t)) }},
+	{"Q", func(t time.Time) string { return fmt.Sprintf("%d", quarter(t)) }},
+	{"MMMM", func(t time.Time) string { return t.Month().String() }},
+	{"MMM", func(t time.Time) string { return t.Month().String()[:3] }},
+	{"MM", func(t time.Time) string { return fmt.Sprintf("%02d", int(t.Month())) }},
+	{"Mo", func(t time.Time) string { return ordinal(int(t.Month())) }},
+	{"M", func(t time.Time) string { return fmt.Sprintf("%d", int(t.Month())) }},
+	{"DDDD", func(t time.Time) string { return fmt.Sprintf("%03d", t.YearDay()) }},
+	{"DDDo", func(t time.Time) string { return ordinal(t.YearDay()) }},
+	{"DDD", func(t time.Time) string { return fmt.Sprintf("%d", t.YearDay()) }},
+	{"DD", func(t time.Time) string { return fmt.Sprintf("%02d", t.Day()) }},
+	{"Do", func(t time.Time) string { return ordinal(t.Day()) }},
+	{"D", func(t time.Time) string { return fmt.Sprintf("%d", t.Day()) }},
+	{"dddd", func(t time.Time) string { return t.Weekday().String() }},
+	{"ddd", func(t time.Time) string { return t.Weekday().String()[:3] }},
+	{"dd", func(t time.Time) string { return t.Weekday().String()[:2] }},
+	{"do", func(t time.Time) string { return ordinal(int(t.Weekday())) }},
+	{"d", func(t time.Time) string { return fmt.Sprintf("%d", int(t.Weekday())) }},
+	{"e", func(t time.Time) string { return fmt.Sprintf("%d", int(t.Weekday())) }},
+	{"E", func(t time.Time) string { return fmt.Sprintf("%d", isoWeekday(t)) }},
+	{"GGGG", func(t time.Time) string { y, _ := t.ISOWeek(); return fmt.Sprintf("%04d", y) }},
+	{"GG", func(t time.Time) string { y, _ := t.ISOWeek(); return fmt.Sprintf("%02d", y%100) }},
+	{"WW", func(t time.Time) string { _, w := t.ISOWeek(); return fmt.Sprintf("%02d", w) }},
+	{"Wo", func(t time.Time) string { _, w := t.ISOWeek(); return ordinal(w) }},
+	{"W", func(t time.Time) string { _, w := t.ISOWeek(); return fmt.Sprintf("%d", w) }},
+	{"gggg", func(t time.Time) string { y, _ := localeWeek(t); return fmt.Sprintf("%04d", y) }},
+	{"gg", func(t time.Time) string { y, _ := localeWeek(t); return fmt.Sprintf("%02d", y%100) }},
+	{"ww", func(t time.Time) string { _, w := localeWeek(t); return fmt.Sprintf("%02d", w) }},
+	{"wo", func(t time.Time) string { _, w := localeWeek(t); return ordinal(w) }},
+	{"w", func(t time.Time) string { _, w := localeWeek(t); return fmt.Sprintf("%d", w) }},
+	{"HH", func(t time.Time) string { return fmt.Sprintf("%02d", t.Hour()) }},
+	{"H", func(t time.Time) string { return fmt.Sprintf("%d", t.Hour()) }},
+	{"hh", func(t time.Time) string { return fmt.Sprintf("%02d", hour12(t)) }},
+	{"h", func(t time.Time) string { return fmt.Sprintf("%d", hour12(t)) }},
+	{"kk", func(t time.Time) string { return fmt.Sprintf("%02d", hour24(t)) }},
+	{"k", func(t time.Time) string { return fmt.Sprintf("%d", hour24(t)) }},
+	{"mm", func(t time.Time) string { return fmt.Sprintf("%02d", t.Minute()) }},
+	{"m", func(t time.Time) string { return fmt.Sprintf("%d", t.Minute()) }},
+	{"ss", func(t time.Time) string { return fmt.Sprintf("%02d", t.Second()) }},
+	{"s", func(t time.Time) string { return fmt.Sprintf("%d", t.Second()) }},
+	{"A", func(t time.Time) string { return t.Format("PM") }},
+	{"a", func(t time.Time) string { return t.Format("pm") }},
+	{"ZZ", func(t time.Time) string { return t.Format("-0700") }},
+	{"Z", func(t time.Time) string { return t.Format("-07:00") }},
+	{"X", func(t time.Time) string { return fmt.Sprintf("%d", t.Unix()) }},
+	{"x", func(t time.Time) string { return fmt.Sprintf("%d", t.UnixMilli()) }},
+	{"zz", func(t time.Time) string { return t.Format("MST") }},
+	{"z", func(t time.Time) string { return t.Format("MST") }},
+}
+
+// formatMoment formats the time using moment.js format string.
+// Text inside square brackets is escaped and characters which are not tokens are kept as it is.
+// Example: `YYYY-MM-DD [at] HH:mm` formats as `2020-07-13 at 20:19`
+func formatMoment(t time.Time, format string) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); {
+		if format[i] == '[' {
+			if end := strings.IndexByte(format[i:], ']'); end > 0 {
+				sb.WriteString(format[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+		// fractional seconds (S, SS, SSS, ... up to 9 digits)
+		if format[i] == 'S' {
+			n := 1
+			for i+n < len(format) && format[i+n] == 'S' {
+				n++
+			}
+			sb.WriteString(fmt.Sprintf("%09d", t.Nanosecond())[:min(n, 9)])
+			i += n
+			continue
+		}
+		matched := false
+		for _, mt := range momentTokens {
+			if strings.HasPrefix(format[i:], mt.token) {
+				sb.WriteString(mt.format(t))
+				i += len(mt.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			sb.WriteByte(format[i])
+			i++
+		}
+	}
+	return sb.String()
+}
+
+func quarter(t time.Time) int {
+	return (int(t.Month())-1)/3 + 1
+}
+
+// isoWeekday returns the day of the week where Monday is 1 and Sunday is 7
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+func hour12(t time.Time) int {
+	if h := t.Hour() % 12; h != 0 {
+		return h
+	}
+	return 12
+}
+
+func hour24(t time.Time) int {
+	if t.Hour() == 0 {
+		return 24
+	}
+	return t.Hour()
+}
+
+// localeWeek returns the week year and week number based on moment.js default (en) locale,
+// where weeks start on Sunday and the week containing January 1st is the first week of the year
+func localeWeek(t time.Time) (year int, week int) {
+	endOfWeek := t.AddDate(0, 0, int(time.Saturday-t.Weekday()))
+	return endOfWeek.Year(), (endOfWeek.YearDay()-1)/7 + 1
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
@@ -0,0 +1,149 @@
+package macros
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/jsonframer"
+	"github.com/grafana/infinity-libs/lib/go/transformations"
+)
+
+// defaultMaxPages limits the number of pages fetched when the max pages is not provided
+const defaultMaxPages = 5
+
+// Page is the state of the current page used by $__page.* macros
+type Page struct {
+	// Number is the page number. Starts from PaginationOptions.StartPage
+	Number int
+	// Offset is the number of rows fetched so far. Starts from PaginationOptions.StartOffset
+	Offset int
+	// Size is the page size
+	Size int
+	// Cursor is the cursor extracted from the previous page response. Empty for the first page
+	Cursor string
+}
+
+// FetchPageFunc fetches the page using the request with page macros applied. Example: the url of the page
+type FetchPageFunc func(ctx context.Context, request string) (response string, err error)
+
+type PaginationOptions struct {
+	// StartPage is the number of the first page. Defaults to 1
+	StartPage int
+	// StartOffset is the offset of the first page
+	StartOffset int
+	// PageSize is the number of rows per page. When set, pagination stops when a page returns fewer rows
+	PageSize int
+	// MaxPages is the maximum number of pages to fetch. Defaults to 5
+	MaxPages int
+	// NextCursorSelector is the root selector used to extract the next cursor from the response.
+	// When set, pagination also stops when the next cursor is empty or same as the current cursor
+	NextCursorSelector string
+	// CursorFramerType is the type of the next cursor selector. `gjson` | `jsonata` | `jq`. Guessed when not set
+	CursorFramerType jsonframer.FramerType
+	// FramerOptions is used to convert each page response into a frame
+	FramerOptions jsonframer.FramerOptions
+}
+
+// Paginate fetches the pages using the built-in macros and merges the page frames into single frame.
+// See Registry.Paginate
+func Paginate(ctx context.Context, input string, args Args, options PaginationOptions, fetch FetchPageFunc) (*data.Frame, error) {
+	return defaultRegistry.Paginate(ctx, input, args, options, fetch)
+}
+
+// Paginate expands the $__page.offset, $__page.number, $__page.size and $__page.cursor macros in the input for each page and fetches the page.
+// Each page response is converted into a frame using the framer options and the frames are merged using transformations.Merge.
+// Pagination stops when a page returns no rows, the page has fewer rows than the page size, the next cursor is empty or max pages are fetched.
+func (r *Registry) Paginate(ctx context.Context, input string, args Args, options PaginationOptions, fetch FetchPageFunc) (*data.Frame, error) {
+	if fetch == nil || options.MaxPages < 0 || options.PageSize < 0 {
+		return nil, ErrInvalidPaginationOptions
+	}
+	maxPages := options.MaxPages
+	if maxPages == 0 {
+		maxPages = defaultMaxPages
+	}
+	page := Page{Number: options.StartPage, Offset: options.StartOffset, Size: options.PageSize}
+	if page.Number == 0 {
+		page.Number = 1
+	}
+	frames := []*data.Frame{}
+	hasMorePages := true
+	for i := 0; i < maxPages && hasMorePages; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		currentPage := page
+		args.Page = &currentPage
+		request, err := r.Apply(input, args)
+		if err != nil {
+			return nil, err
+		}
+		response, err := fetch(ctx, request)
+		if err != nil {
+			return nil, errors.Join(ErrFetchingPage, fmt.Errorf("page %d. %w", page.Number, err))
+		}
+		frame, err := jsonframer.ToFrame(response, options.FramerOptions)
+		if err != nil {
+			return nil, errors.Join(ErrFetchingPage, fmt.Errorf("page %d. %w", page.Number, err))
+		}
+		rows := 0
+		if frame != nil {
+			rows = frame.Rows()
+		}
+		if rows == 0 {
+			hasMorePages = false
+			break
+		}
+		frames = append(frames, frame)
+		hasMorePages = options.PageSize == 0 || rows >= options.PageSize
+		if options.NextCursorSelector != "" {
+			page.Cursor = nextCursor(response, options)
+			hasMorePages = hasMorePages && page.Cursor != "" && page.Cursor != currentPage.Cursor
+		}
+		page.Number++
+		page.Offset += max(options.PageSize, rows)
+	}
+	if len(frames) == 0 {
+		return data.NewFrame(options.FramerOptions.FrameName), nil
+	}
+	frame, err := transformations.Merge(frames, transformations.MergeFramesOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if hasMorePages {
+		frame.AppendNotices(data.Notice{Severity: data.NoticeSeverityWarning, Text: fmt.Sprintf("pagination stopped after fetching max pages (%d). results may be incomplete", maxPages)})
+	}
+	return frame, nil
+}
+
+// nextCursor extracts the next cursor from the page response. Returns empty string when the cursor not found
+func nextCursor(response string, options PaginationOptions) string {
+	cursor, err := jsonframer.ApplyRootSelector(response, options.NextCursorSelector, options.CursorFramerType)
+	if err != nil {
+		return ""
+	}
+	cursor = strings.TrimSpace(cursor)
+	var value any
+	if err := json.Unmarshal([]byte(cursor), &value); err == nil {
+		switch v := value.(type) {
+		case nil:
+			return ""
+		case string:
+			return v
+		}
+	}
+	return cursor
+}
+
+// pageMacro returns the macro function for the page fields. The macro is kept as it is when the page is not available
+func pageMacro(field func(p *Page) string) MacroFunc {
+	return func(args []string, options Args) (string, error) {
+		if options.Page == nil {
+			return "", errMacroNotApplied
+		}
+		return field(options.Page), nil
+	}
+}
@@ -0,0 +1,109 @@
+package macros
+
+import "strings"
+
+// functionMacroMatch is an occurrence of $__name or $__name(args) macro in the input string
+type functionMacroMatch struct {
+	start int
+	end   int
+	args  []string
+}
+
+// findFunctionMacros finds the occurrences of $__name and $__name(args) macro.
+// Arguments are split by the top level commas. Commas inside quoted strings and nested parentheses are not treated as separators.
+// Example: $__fn(concat(a,b), 'x,y') has two arguments `concat(a,b)` and `'x,y'`
+func findFunctionMacros(name string, input string) []functionMacroMatch {
+	prefix := "$__" + name
+	matches := []functionMacroMatch{}
+	for i := 0; i < len(input); {
+		idx := strings.Index(input[i:], prefix)
+		if idx < 0 {
+			break
+		}
+		start := i + idx
+		end := start + len(prefix)
+		if end < len(input) && isWordChar(input[end]) {
+			// prefix of another macro. Example: $__interval in $__interval_ms
+			i = end
+			continue
+		}
+		args := []string{""}
+		if end < len(input) && input[end] == '(' {
+			if closing := findClosingParenthesis(input, end); closing >= 0 {
+				args = splitMacroArgs(input[end+1 : closing])
+				end = closing + 1
+			}
+		}
+		matches = append(matches, functionMacroMatch{start: start, end: end, args: args})
+		i = end
+	}
+	return matches
+}
+
+// splitMacroArgs splits the macro arguments by the top level commas and trims the spaces around the arguments.
+// Quoted strings (single, double or back quotes) with backslash escapes and balanced brackets are kept as it is.
+func splitMacroArgs(input string) []string {
+	return splitMacroArgsN(input, -1)
+}
+
+// splitMacroArgsN is same as splitMacroArgs but returns at most n arguments. The last argument is the rest of the input.
+// Negative n returns all the arguments
+func splitMacroArgsN(input string, n int) []string {
+	args := []string{}
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0 && (n < 0 || len(args) < n-1):
+			args = append(args, strings.TrimSpace(input[start:i]))
+			start = i + 1
+		}
+	}
+	return append(args, strings.TrimSpace(input[start:]))
+}
+
+// findClosingParenthesis returns the index of the parenthesis closing the one at the start index.
+// Parentheses inside quoted strings are ignored. Returns -1 when not found
+func findClosingParenthesis(input string, start int) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
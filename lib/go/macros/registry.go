@@ -0,0 +1,298 @@
+package macros
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// MacroFunc expands a single occurrence of a macro.
+// args are the arguments of $__name(args) macro or the format of ${__name:format} macro split by comma
+// and options are the arguments passed to ApplyMacros / Registry.Apply
+type MacroFunc func(args []string, options Args) (string, error)
+
+// Syntax denotes the macro syntaxes a registered macro is expanded for
+type Syntax int
+
+const (
+	// SyntaxFunction is the $__name(args) and $__name syntax
+	SyntaxFunction Syntax = 1 << iota
+	// SyntaxVariable is the ${__name} and ${__name:format} syntax
+	SyntaxVariable
+	// SyntaxAll is both function and variable syntax
+	SyntaxAll = SyntaxFunction | SyntaxVariable
+)
+
+// errMacroNotApplied is returned by the macro functions to keep the macro as it is in the input
+var errMacroNotApplied = errors.New("macro not applied")
+
+var macroNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+type registeredMacro struct {
+	name   string
+	syntax Syntax
+	fn     MacroFunc
+	// text macros receive the first argument and the rest of the arguments as a single text argument. Example: $__if(${x}, a, b)
+	text bool
+}
+
+// Registry holds the macros to be applied. Macros are applied in the order they are registered.
+// Registry is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	macros []registeredMacro
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewDefaultRegistry returns a registry pre-populated with the built-in macros.
+// Custom macros registered to the returned registry are applied after the built-in macros.
+func NewDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.mustRegisterText("if", SyntaxFunction, ifMacro)         // $__if(condition, text)
+	r.mustRegister("from", SyntaxAll, fromMacro)              // ${__from}
+	r.mustRegister("timeFrom", SyntaxVariable, fromMacro)     // ${__timeFrom}
+	r.mustRegister("to", SyntaxAll, toMacro)                  // ${__to}
+	r.mustRegister("timeTo", SyntaxVariable, toMacro)         // ${__timeTo}
+	r.mustRegister("now", SyntaxAll, nowMacro)                // $__now, ${__now}
+	r.mustRegister("interval_ms", SyntaxAll, intervalMsMacro) // $__interval_ms, ${__interval_ms}
+	r.mustRegister("interval", SyntaxAll, intervalMacro)      // $__interval, ${__interval}
+	r.mustRegister("timeFilter", SyntaxFunction, timeFilterMacro)
+	r.mustRegister("timeGroup", SyntaxFunction, timeGroupMacro)
+	r.mustRegister("unixEpochFilter", SyntaxFunction, unixEpochFilterMacro)
+	r.mustRegister("timeFrom", SyntaxFunction, timeFromMacro) // $__timeFrom()
+	r.mustRegister("timeTo", SyntaxFunction, timeToMacro)     // $__timeTo()
+	r.mustRegister("user.name", SyntaxVariable, userMacro(func(u *backend.User) string { return u.Name }))
+	r.mustRegister("user.email", SyntaxVariable, userMacro(func(u *backend.User) string { return u.Email }))
+	r.mustRegister("user.login", SyntaxVariable, userMacro(func(u *backend.User) string { return u.Login }))
+	r.mustRegister("user.role", SyntaxVariable, userMacro(func(u *backend.User) string { return u.Role }))
+	r.mustRegister("org.id", SyntaxVariable, orgIDMacro)
+	r.mustRegister("org.name", SyntaxVariable, orgNameMacro)
+	r.mustRegister("datasource.uid", SyntaxVariable, dataSourceMacro(func(ds *backend.DataSourceInstanceSettings) string { return ds.UID }))
+	r.mustRegister("datasource.name", SyntaxVariable, dataSourceMacro(func(ds *backend.DataSourceInstanceSettings) string { return ds.Name }))
+	r.mustRegister("query.refId", SyntaxVariable, queryMacro(func(q *backend.DataQuery) string { return q.RefID }))
+	r.mustRegister("query.maxDataPoints", SyntaxVariable, queryMacro(func(q *backend.DataQuery) string { return fmt.Sprintf("%d", q.MaxDataPoints) }))
+	r.mustRegister("page.offset", SyntaxAll, pageMacro(func(p *Page) string { return fmt.Sprintf("%d", p.Offset) }))
+	r.mustRegister("page.number", SyntaxAll, pageMacro(func(p *Page) string { return fmt.Sprintf("%d", p.Number) }))
+	r.mustRegister("page.size", SyntaxAll, pageMacro(func(p *Page) string { return fmt.Sprintf("%d", p.Size) }))
+	r.mustRegister("page.cursor", SyntaxAll, pageMacro(func(p *Page) string { return p.Cursor }))
+	r.mustRegister("query.intervalMs", SyntaxVariable, queryMacro(func(q *backend.DataQuery) string { return fmt.Sprintf("%d", q.Interval.Milliseconds()) }))
+	return r
+}
+
+// Register registers the macro for both $__name(args) and ${__name:format} syntaxes.
+// Registering an existing name replaces the macro.
+func (r *Registry) Register(name string, fn MacroFunc) error {
+	return r.RegisterWithSyntax(name, SyntaxAll, fn)
+}
+
+// RegisterWithSyntax registers the macro only for the given syntaxes.
+// Registering an existing name and syntax replaces the macro.
+func (r *Registry) RegisterWithSyntax(name string, syntax Syntax, fn MacroFunc) error {
+	if !macroNameRegex.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidMacroName, name)
+	}
+	if fn == nil || syntax&SyntaxAll == 0 {
+		return fmt.Errorf("%w: %q", ErrInvalidMacro, name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for idx, m := range r.macros {
+		if m.name != name || m.syntax&syntax == 0 {
+			continue
+		}
+		if m.syntax == syntax {
+			r.macros[idx].fn = fn
+			r.macros[idx].text = false
+			return nil
+		}
+		// the existing macro remains for the syntaxes not being replaced
+		r.macros[idx].syntax = m.syntax &^ syntax
+	}
+	r.macros = slices.DeleteFunc(r.macros, func(m registeredMacro) bool { return m.syntax == 0 })
+	r.macros = append(r.macros, registeredMacro{name: name, syntax: syntax, fn: fn})
+	return nil
+}
+
+// Unregister removes the macro from the registry
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.macros = slices.DeleteFunc(r.macros, func(m registeredMacro) bool { return m.name == name })
+}
+
+// Names returns the names of the registered macros
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := []string{}
+	for _, m := range r.macros {
+		if !slices.Contains(names, m.name) {
+			names = append(names, m.name)
+		}
+	}
+	return names
+}
+
+// Apply interpolates the template variables and then applies the registered macros to the input string.
+// In strict mode, unknown and unbalanced macros are returned as error.
+func (r *Registry) Apply(input string, args Args) (string, error) {
+	out, _, err := r.apply(input, args, false)
+	return out, err
+}
+
+// ApplyWithDiagnostics is same as Apply and additionally returns the diagnostics of unknown macros, malformed arguments and unbalanced parentheses/braces.
+// Macros with bad arguments are reported as diagnostics and kept as it is instead of failing, unless strict mode is enabled.
+func (r *Registry) ApplyWithDiagnostics(input string, args Args) (string, []Diagnostic, error) {
+	return r.apply(input, args, true)
+}
+
+func (r *Registry) apply(input string, args Args, collectBadArguments bool) (string, []Diagnostic, error) {
+	if err := args.Context.validate(); err != nil {
+		return input, nil, err
+	}
+	loc, err := args.loadLocation()
+	if err != nil {
+		return input, nil, err
+	}
+	args.location = loc
+	args = args.withContextDefaults()
+	if args.Now.IsZero() {
+		args.Now = time.Now()
+	}
+	r.mu.RLock()
+	e := &expansion{args: args, macros: slices.Clone(r.macros), tokens: scanMacros(input), invocations: map[string]int{}, collectBadArguments: collectBadArguments}
+	r.mu.RUnlock()
+	e.diagnostics = r.diagnose(e.tokens)
+	input, err = e.expand(interpolateVariables(input, args.Variables, args.Context))
+	diagnostics := e.diagnostics
+	if err != nil {
+		return input, diagnostics, err
+	}
+	diagnostics = sortDiagnostics(diagnostics)
+	if args.Strict && len(diagnostics) > 0 {
+		errs := []error{ErrMacroDiagnostics}
+		for _, d := range diagnostics {
+			errs = append(errs, d)
+		}
+		return input, diagnostics, errors.Join(errs...)
+	}
+	return input, diagnostics, nil
+}
+
+// expansion holds the state of a single Apply call
+type expansion struct {
+	args   Args
+	macros []registeredMacro
+	// tokens are the macros found in the input before the interpolation. Used to find the offset of the invoked macros
+	tokens []macroToken
+	// invocations counts the invocations of each macro name and syntax
+	invocations         map[string]int
+	collectBadArguments bool
+	diagnostics         []Diagnostic
+}
+
+// expand applies the macros in the order they are registered
+func (e *expansion) expand(input string) (string, error) {
+	var err error
+	for _, m := range e.macros {
+		if m.syntax&SyntaxVariable != 0 {
+			input, err = applyMacro("$$"+m.name, input, func(match string, macroArgs []string) (string, error) {
+				res, err := e.invoke(m, match, macroArgs)
+				return e.args.Context.escape(res), err
+			})
+			if err != nil {
+				return input, err
+			}
+		}
+		if m.syntax&SyntaxFunction != 0 {
+			input, err = applyMacro(m.name, input, func(match string, macroArgs []string) (string, error) {
+				res, err := e.invoke(m, match, macroArgs)
+				if match == "$__"+m.name {
+					// macros without arguments such as $__page.cursor are values
+					return e.args.Context.escape(res), err
+				}
+				return res, err
+			})
+			if err != nil {
+				return input, err
+			}
+		}
+	}
+	return input, nil
+}
+
+// invoke expands the macros nested in the arguments and then invokes the macro.
+// Bad arguments are recorded as diagnostics instead of failing, when enabled
+func (e *expansion) invoke(m registeredMacro, match string, macroArgs []string) (string, error) {
+	offset := e.offset(m.name, match)
+	if open := strings.Index(match, "("); m.text && strings.HasPrefix(match, "$__") && open >= 0 && strings.HasSuffix(match, ")") {
+		macroArgs = splitMacroArgsN(match[open+1:len(match)-1], 2)
+	}
+	for idx, arg := range macroArgs {
+		if !strings.Contains(arg, "$__") && !strings.Contains(arg, "${__") {
+			continue
+		}
+		expanded, err := e.expand(arg)
+		if err != nil {
+			return "", err
+		}
+		macroArgs[idx] = expanded
+	}
+	res, err := m.fn(macroArgs, e.args)
+	if err != nil && e.collectBadArguments && isBadArguments(err) {
+		e.diagnostics = append(e.diagnostics, Diagnostic{Macro: match, Offset: offset, Kind: DiagnosticKindBadArguments, Message: err.Error()})
+		return "", errMacroNotApplied
+	}
+	return res, err
+}
+
+// offset returns the offset of the macro occurrence in the input. Macros are invoked in the order of their occurrence for each name and syntax.
+// Returns -1 when the occurrence is not found in the input such as the macros introduced by the template variables
+func (e *expansion) offset(name string, match string) int {
+	syntax := SyntaxFunction
+	if strings.HasPrefix(match, "${__") {
+		syntax = SyntaxVariable
+	}
+	key := fmt.Sprintf("%d:%s", syntax, name)
+	occurrence := e.invocations[key]
+	e.invocations[key]++
+	for _, token := range e.tokens {
+		if token.name != name || token.syntax != syntax {
+			continue
+		}
+		if occurrence == 0 {
+			return token.offset
+		}
+		occurrence--
+	}
+	return -1
+}
+
+// has checks whether the macro is registered for the syntax
+func (r *Registry) has(name string, syntax Syntax) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.ContainsFunc(r.macros, func(m registeredMacro) bool { return m.name == name && m.syntax&syntax != 0 })
+}
+
+func (r *Registry) mustRegister(name string, syntax Syntax, fn MacroFunc) {
+	if err := r.RegisterWithSyntax(name, syntax, fn); err != nil {
+		panic(err)
+	}
+}
+
+// mustRegisterText registers the text macro. See registeredMacro.text
+func (r *Registry) mustRegisterText(name string, syntax Syntax, fn MacroFunc) {
+	r.mustRegister(name, syntax, fn)
+	r.macros[len(r.macros)-1].text = true
+}
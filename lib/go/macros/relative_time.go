@@ -0,0 +1,90 @@
+package macros
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var relativeTimeRegex = regexp.MustCompile(`^(now)?((?:[+-]\d+(?:s|m|h|d|w|M|y))*)(?:/(s|m|h|d|w|M|y))?$`)
+var relativeTimeOffsetRegex = regexp.MustCompile(`([+-])(\d+)(s|m|h|d|w|M|y)`)
+
+// isRelativeTime checks whether the macro argument is a relative time expression such as `-1h`, `now-7d/d` or `/d`
+func isRelativeTime(expr string) bool {
+	return strings.HasPrefix(expr, "now") || strings.HasPrefix(expr, "+") || strings.HasPrefix(expr, "-") || strings.HasPrefix(expr, "/")
+}
+
+// ParseRelativeTime evaluates a Grafana style relative time expression such as `now-7d/d` or `-1h` against the anchor time.
+// Expressions starting with `now` are evaluated against the now time, other expressions are evaluated against the anchor.
+// Supported units are s, m, h, d, w, M and y. When roundUp is true, the rounding (`/unit`) moves the time to the end of the unit instead of the start.
+// Weeks start on Sunday.
+func ParseRelativeTime(expr string, anchor time.Time, now time.Time, roundUp bool) (time.Time, error) {
+	expr = strings.TrimSpace(expr)
+	matches := relativeTimeRegex.FindStringSubmatch(expr)
+	if expr == "" || matches == nil {
+		return anchor, fmt.Errorf("%w: %q", ErrInvalidRelativeTime, expr)
+	}
+	t := anchor
+	if matches[1] == "now" {
+		t = now
+	}
+	for _, offset := range relativeTimeOffsetRegex.FindAllStringSubmatch(matches[2], -1) {
+		n, err := strconv.Atoi(offset[2])
+		if err != nil {
+			return anchor, fmt.Errorf("%w: %q", ErrInvalidRelativeTime, expr)
+		}
+		if offset[1] == "-" {
+			n = -n
+		}
+		t = addTimeUnit(t, n, offset[3])
+	}
+	if matches[3] != "" {
+		t = startOfTimeUnit(t, matches[3])
+		if roundUp {
+			t = addTimeUnit(t, 1, matches[3]).Add(-time.Millisecond)
+		}
+	}
+	return t, nil
+}
+
+func addTimeUnit(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "s":
+		return t.Add(time.Duration(n) * time.Second)
+	case "m":
+		return t.Add(time.Duration(n) * time.Minute)
+	case "h":
+		return t.Add(time.Duration(n) * time.Hour)
+	case "d":
+		return t.AddDate(0, 0, n)
+	case "w":
+		return t.AddDate(0, 0, n*7)
+	case "M":
+		return t.AddDate(0, n, 0)
+	case "y":
+		return t.AddDate(n, 0, 0)
+	}
+	return t
+}
+
+func startOfTimeUnit(t time.Time, unit string) time.Time {
+	switch unit {
+	case "s":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	case "m":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
+	case "h":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case "d":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case "w":
+		return time.Date(t.Year(), t.Month(), t.Day()-int(t.Weekday()), 0, 0, 0, 0, t.Location())
+	case "M":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case "y":
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
@@ -0,0 +1,106 @@
+package macros
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// Dialect renders the SQL macros for a specific database
+type Dialect interface {
+	// TimeFilter renders $__timeFilter(column)
+	TimeFilter(column string, timeRange backend.TimeRange) string
+	// TimeGroup renders $__timeGroup(column, interval)
+	TimeGroup(column string, interval time.Duration) string
+	// UnixEpochFilter renders $__unixEpochFilter(column)
+	UnixEpochFilter(column string, timeRange backend.TimeRange) string
+	// TimeFrom renders $__timeFrom()
+	TimeFrom(timeRange backend.TimeRange) string
+	// TimeTo renders $__timeTo()
+	TimeTo(timeRange backend.TimeRange) string
+}
+
+// timeFilterMacro expands $__timeFilter(column)
+func timeFilterMacro(args []string, options Args) (string, error) {
+	if options.Dialect == nil {
+		return "", errMacroNotApplied
+	}
+	column, err := getColumnArg("timeFilter", args)
+	if err != nil {
+		return "", err
+	}
+	return options.Dialect.TimeFilter(column, options.TimeRange), nil
+}
+
+// timeGroupMacro expands $__timeGroup(column, interval)
+func timeGroupMacro(args []string, options Args) (string, error) {
+	if options.Dialect == nil {
+		return "", errMacroNotApplied
+	}
+	if len(args) != 2 || strings.TrimSpace(args[0]) == "" {
+		return "", fmt.Errorf("%w: $__timeGroup expects column and interval arguments", ErrInvalidMacroArguments)
+	}
+	interval, err := parseInterval(strings.TrimSpace(args[1]))
+	if err != nil {
+		return "", fmt.Errorf("%w: $__timeGroup invalid interval %q", ErrInvalidMacroArguments, strings.TrimSpace(args[1]))
+	}
+	return options.Dialect.TimeGroup(strings.TrimSpace(args[0]), interval), nil
+}
+
+// unixEpochFilterMacro expands $__unixEpochFilter(column)
+func unixEpochFilterMacro(args []string, options Args) (string, error) {
+	if options.Dialect == nil {
+		return "", errMacroNotApplied
+	}
+	column, err := getColumnArg("unixEpochFilter", args)
+	if err != nil {
+		return "", err
+	}
+	return options.Dialect.UnixEpochFilter(column, options.TimeRange), nil
+}
+
+// timeFromMacro expands $__timeFrom()
+func timeFromMacro(args []string, options Args) (string, error) {
+	if options.Dialect == nil {
+		return "", errMacroNotApplied
+	}
+	return options.Dialect.TimeFrom(options.TimeRange), nil
+}
+
+// timeToMacro expands $__timeTo()
+func timeToMacro(args []string, options Args) (string, error) {
+	if options.Dialect == nil {
+		return "", errMacroNotApplied
+	}
+	return options.Dialect.TimeTo(options.TimeRange), nil
+}
+
+func getColumnArg(macroName string, args []string) (string, error) {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return "", fmt.Errorf("%w: $__%s expects column argument", ErrInvalidMacroArguments, macroName)
+	}
+	return strings.TrimSpace(args[0]), nil
+}
+
+// parseInterval parses the interval such as 5m, 1h or 1d. Unlike time.ParseDuration, d (day), w (week) and y (year) units are supported
+func parseInterval(interval string) (time.Duration, error) {
+	d, err := time.ParseDuration(interval)
+	for unit, unitDuration := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(interval, unit); ok {
+			var v float64
+			v, err = strconv.ParseFloat(n, 64)
+			d = time.Duration(v * float64(unitDuration))
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, errors.New("interval should be greater than zero")
+	}
+	return d, nil
+}
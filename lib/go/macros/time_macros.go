@@ -4,36 +4,43 @@ import (
 	"fmt"
 	"strings"
 	"time"
-
-	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
-func from(inputString string, timeRange backend.TimeRange) (string, error) {
-	res, err := applyMacro("$$from", inputString, func(query string, args []string) (string, error) {
-		return expandTimeMacro(timeRange.From.UTC(), args)
-	})
-	return res, err
+func fromMacro(args []string, options Args) (string, error) {
+	return expandRelativeTimeMacro(options.TimeRange.From, options.Now, options.timeLocation(), false, args)
 }
 
-func timeFrom(inputString string, timeRange backend.TimeRange) (string, error) {
-	res, err := applyMacro("$$timeFrom", inputString, func(query string, args []string) (string, error) {
-		return expandTimeMacro(timeRange.From.UTC(), args)
-	})
-	return res, err
+func toMacro(args []string, options Args) (string, error) {
+	return expandRelativeTimeMacro(options.TimeRange.To, options.Now, options.timeLocation(), true, args)
 }
 
-func to(inputString string, timeRange backend.TimeRange) (string, error) {
-	res, err := applyMacro("$$to", inputString, func(query string, args []string) (string, error) {
-		return expandTimeMacro(timeRange.To.UTC(), args)
-	})
-	return res, err
+// nowMacro expands $__now and ${__now} macros. Same as ${__from}, relative expressions and formats are supported. Example: ${__now:-7d/d:date:iso}
+func nowMacro(args []string, options Args) (string, error) {
+	return expandRelativeTimeMacro(options.Now, options.Now, options.timeLocation(), false, args)
 }
 
-func timeTo(inputString string, timeRange backend.TimeRange) (string, error) {
-	res, err := applyMacro("$$timeTo", inputString, func(query string, args []string) (string, error) {
-		return expandTimeMacro(timeRange.To.UTC(), args)
-	})
-	return res, err
+// expandRelativeTimeMacro applies the relative time expression (if any) found in the first argument and then formats the time.
+// Time is rounded and formatted in the given location unless the macro ends with `:utc`.
+// Example: ${__from:-1h}, ${__from:now-7d/d:date:iso}, ${__to:/d}, ${__from:date:YYYY-MM-DD:utc}
+func expandRelativeTimeMacro(t time.Time, now time.Time, loc *time.Location, roundUp bool, args []string) (string, error) {
+	arg := strings.Join(args, ",")
+	if arg == "utc" || strings.HasSuffix(arg, ":utc") {
+		arg = strings.TrimSuffix(strings.TrimSuffix(arg, "utc"), ":")
+		loc = time.UTC
+	}
+	t, now = t.In(loc), now.In(loc)
+	if !isRelativeTime(arg) {
+		return expandTimeMacro(t, strings.Split(arg, ","))
+	}
+	expr, format, _ := strings.Cut(arg, ":")
+	t, err := ParseRelativeTime(expr, t, now, roundUp)
+	if err != nil {
+		return "", err
+	}
+	if format == "" {
+		return expandTimeMacro(t, nil)
+	}
+	return expandTimeMacro(t, strings.Split(format, ","))
 }
 
 func expandTimeMacro(t time.Time, args []string) (string, error) {
@@ -42,46 +49,15 @@ func expandTimeMacro(t time.Time, args []string) (string, error) {
 	}
 	if args[0] == "date" {
 		if len(args) < 2 || args[1] == ":iso" {
-			return t.Format("2006-01-02T15:04:05.999Z"), nil
+			return t.UTC().Format("2006-01-02T15:04:05.999Z"), nil
 		}
 	}
 	format := strings.TrimPrefix(strings.Join(args, ","), "date:")
 	if format == "iso" {
-		return t.Format("2006-01-02T15:04:05.999Z"), nil
+		return t.UTC().Format("2006-01-02T15:04:05.999Z"), nil
 	}
 	if format == "seconds" {
 		return fmt.Sprintf("%d", t.Unix()), nil
 	}
-	format = strings.ReplaceAll(format, "YYYY", "2006")
-	format = strings.ReplaceAll(format, "YY", "06")
-
-	format = strings.ReplaceAll(format, "MMMM", "January")
-	format = strings.ReplaceAll(format, "MMM", "Jan")
-	format = strings.ReplaceAll(format, "MM", "01")
-	format = strings.ReplaceAll(format, "M", "1")
-
-	format = strings.ReplaceAll(format, "DD", "02")
-	format = strings.ReplaceAll(format, "D", "2")
-
-	format = strings.ReplaceAll(format, "hh", "03")
-	format = strings.ReplaceAll(format, "h", "3")
-	format = strings.ReplaceAll(format, "HH", "15")
-
-	format = strings.ReplaceAll(format, "mm", "04")
-	format = strings.ReplaceAll(format, "m", "4")
-
-	format = strings.ReplaceAll(format, "ss", "05")
-	format = strings.ReplaceAll(format, "s", "5")
-
-	format = strings.ReplaceAll(format, "S", "0")
-
-	format = strings.ReplaceAll(format, "A", "PM")
-
-	format = strings.ReplaceAll(format, "zz", "MST")
-	format = strings.ReplaceAll(format, "z", "MST")
-
-	format = strings.ReplaceAll(format, "dddd", "Monday")
-	format = strings.ReplaceAll(format, "ddd", "Mon")
-
-	return t.Format(format), nil
+	return formatMoment(t, format), nil
 }
@@ -0,0 +1,158 @@
+package macros
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Variable is the value of a dashboard template variable
+type Variable struct {
+	Values []string
+	// Multi denotes the multi-value variable. Single value variables use the first value
+	Multi bool
+}
+
+// SingleValueVariable returns a single value template variable
+func SingleValueVariable(value string) Variable {
+	return Variable{Values: []string{value}}
+}
+
+// MultiValueVariable returns a multi-value template variable
+func MultiValueVariable(values ...string) Variable {
+	return Variable{Values: values, Multi: true}
+}
+
+// Variable formats. Same as the Grafana template variable formats
+const (
+	VariableFormatGlob          = "glob"
+	VariableFormatRaw           = "raw"
+	VariableFormatCSV           = "csv"
+	VariableFormatJSON          = "json"
+	VariableFormatRegex         = "regex"
+	VariableFormatPipe          = "pipe"
+	VariableFormatSQLString     = "sqlstring"
+	VariableFormatQueryParam    = "queryparam"
+	VariableFormatSingleQuote   = "singlequote"
+	VariableFormatDoubleQuote   = "doublequote"
+	VariableFormatPercentEncode = "percentencode"
+)
+
+// defaultValueRegex matches the ${var:default=value} template variable. Macros (names starting with __) are not matched
+var defaultValueRegex = regexp.MustCompile(`\$\{([A-Za-z0-9]\w*|_[A-Za-z0-9]\w*):default=([^}]*)\}`)
+
+// interpolateVariables replaces $var, ${var} and ${var:format} with the template variable values.
+// ${var:default=value} is replaced with the value when the variable is not available or empty.
+// Values are escaped to match the context unless the raw format is used. See escapeVariable
+func interpolateVariables(input string, variables map[string]Variable, context EscapeContext) string {
+	input = defaultValueRegex.ReplaceAllStringFunc(input, func(match string) string {
+		groups := defaultValueRegex.FindStringSubmatch(match)
+		variable, ok := variables[groups[1]]
+		if !ok || len(variable.Values) == 0 || strings.Join(variable.Values, "") == "" {
+			return groups[2]
+		}
+		return context.escape(formatVariable(groups[1], variable, ""))
+	})
+	if len(variables) == 0 {
+		return input
+	}
+	names := []string{}
+	for name := range variables {
+		names = append(names, name)
+	}
+	// longer names first so that $foobar is not interpolated as $foo
+	slices.SortFunc(names, func(a, b string) int { return len(b) - len(a) })
+	for _, name := range names {
+		variable := variables[name]
+		rgx := regexp.MustCompile(`\$\{` + regexp.QuoteMeta(name) + `(?::([^}]*))?\}|\$` + regexp.QuoteMeta(name) + `\b`)
+		input = rgx.ReplaceAllStringFunc(input, func(match string) string {
+			format := ""
+			if groups := rgx.FindStringSubmatch(match); len(groups) > 1 {
+				format = groups[1]
+			}
+			return escapeVariable(formatVariable(name, variable, format), format, context)
+		})
+	}
+	return input
+}
+
+// escapeVariable escapes the formatted variable value to match the context. Values with raw format are not escaped.
+// Values with queryparam and percentencode formats are already url encoded and not escaped again in the url contexts
+func escapeVariable(value string, format string, context EscapeContext) string {
+	switch {
+	case format == VariableFormatRaw:
+		return value
+	case (format == VariableFormatQueryParam || format == VariableFormatPercentEncode) && (context == EscapeContextURLQuery || context == EscapeContextURLPath):
+		return value
+	default:
+		return context.escape(value)
+	}
+}
+
+// formatVariable formats the template variable value based on the Grafana formatting semantics.
+// Unknown formats fallback to glob
+func formatVariable(name string, variable Variable, format string) string {
+	values := variable.Values
+	if !variable.Multi && len(values) > 1 {
+		values = values[:1]
+	}
+	switch format {
+	case VariableFormatRaw, VariableFormatCSV:
+		return strings.Join(values, ",")
+	case VariableFormatJSON:
+		if variable.Multi {
+			return toJSON(values)
+		}
+		return toJSON(strings.Join(values, ""))
+	case VariableFormatRegex:
+		escaped := mapValues(values, regexp.QuoteMeta)
+		if !variable.Multi || len(escaped) == 1 {
+			return strings.Join(escaped, "")
+		}
+		return "(" + strings.Join(escaped, "|") + ")"
+	case VariableFormatPipe:
+		return strings.Join(values, "|")
+	case VariableFormatSQLString:
+		return strings.Join(mapValues(values, func(v string) string { return "'" + strings.ReplaceAll(v, "'", "''") + "'" }), ",")
+	case VariableFormatSingleQuote:
+		return strings.Join(mapValues(values, func(v string) string { return "'" + strings.ReplaceAll(v, "'", `\'`) + "'" }), ",")
+	case VariableFormatDoubleQuote:
+		return strings.Join(mapValues(values, func(v string) string { return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"` }), ",")
+	case VariableFormatQueryParam:
+		return strings.Join(mapValues(values, func(v string) string { return "var-" + percentEncode(name) + "=" + percentEncode(v) }), "&")
+	case VariableFormatPercentEncode:
+		if !variable.Multi {
+			return percentEncode(strings.Join(values, ""))
+		}
+		return percentEncode("{" + strings.Join(values, ",") + "}")
+	default:
+		if variable.Multi && len(values) > 1 {
+			return "{" + strings.Join(values, ",") + "}"
+		}
+		return strings.Join(values, "")
+	}
+}
+
+// percentEncode encodes the value similar to javascript's encodeURIComponent
+func percentEncode(v string) string {
+	return strings.ReplaceAll(url.QueryEscape(v), "+", "%20")
+}
+
+// toJSON marshals the value without escaping html characters
+func toJSON(v any) string {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func mapValues(values []string, fn func(string) string) []string {
+	out := []string{}
+	for _, v := range values {
+		out = append(out, fn(v))
+	}
+	return out
+}
@@ -13,6 +13,27 @@ type ComputedColumn struct {
 	Text     string `json:"text"`
 }
 
+type ComputedColumnsOptions struct {
+	Columns []ComputedColumn `json:"columns,omitempty"`
+}
+
+// ComputedColumns adds the computed columns to each frame
+func ComputedColumns(input []*data.Frame, options ComputedColumnsOptions) ([]*data.Frame, error) {
+	output := []*data.Frame{}
+	for _, frame := range input {
+		if frame == nil {
+			output = append(output, frame)
+			continue
+		}
+		computedFrame, err := GetFrameWithComputedColumns(frame, options.Columns)
+		if err != nil {
+			return output, err
+		}
+		output = append(output, computedFrame)
+	}
+	return output, nil
+}
+
 func GetFrameWithComputedColumns(frame *data.Frame, columns []ComputedColumn) (*data.Frame, error) {
 	var err error
 	frameLen := frame.Rows()
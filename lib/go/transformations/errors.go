@@ -4,14 +4,37 @@ import "errors"
 
 var (
 	ErrSummarizeByFieldNotFound   = errors.New("summarize by field not found. Not applying summarize")
+	ErrSortByFieldNotFound        = errors.New("sort by field not found. Not applying sort")
+	ErrGroupByFieldNotFound       = errors.New("group by field not found. Not applying group by")
 	ErrNotUniqueFieldNames        = errors.New("field names are not unique. Not applying filter")
 	ErrEvaluatingFilterExpression = errors.New("error evaluating filter expression")
 	ErrInvalidFilterExpression    = errors.New("invalid filter expression")
 
-	ErrMergeTransformationNoFrameSupplied     = errors.New("no frames supplied for merge frame transformation")
+	ErrEvaluatingGroupByAggregation = errors.New("error evaluating group by aggregation")
+
+	ErrMergeTransformationNoFrameSupplied = errors.New("no frames supplied for merge frame transformation")
+
+	// not returned by Merge anymore. frames with different fields are merged as the union of the fields
 	ErrMergeTransformationDifferentFields     = errors.New("unable to merge fields due to different fields")
 	ErrMergeTransformationDifferentFieldNames = errors.New("unable to merge field due to different field names")
 	ErrMergeTransformationDifferentFieldTypes = errors.New("unable to merge fields due to different field types")
 
+	ErrJoinTransformationNoFrameSupplied = errors.New("no frames supplied for join transformation")
+	ErrInvalidJoinOptions                = errors.New("invalid join options")
+	ErrJoinFieldNotFound                 = errors.New("join field not found")
+	ErrJoinFieldTypeMismatch             = errors.New("unable to join due to different key field types")
+	ErrJoinFieldNameCollision            = errors.New("unable to join due to same field name in multiple frames")
+
+	ErrPivotFieldNotFound         = errors.New("pivot field not found. Not applying pivot")
+	ErrPivotColumnNameCollision   = errors.New("unable to pivot due to null and \"null\" column values")
+	ErrUnpivotFieldNotFound       = errors.New("unpivot field not found. Not applying unpivot")
+	ErrUnpivotDifferentFieldTypes = errors.New("unable to unpivot fields due to different field types")
+	ErrRowsToFieldsFieldNotFound  = errors.New("rows to fields field not found. Not applying rows to fields")
+
+	ErrInvalidPipeline              = errors.New("invalid transformations pipeline")
+	ErrUnknownTransformation        = errors.New("unknown transformation")
+	ErrInvalidTransformationOptions = errors.New("invalid transformation options")
+	ErrTransformationFailed         = errors.New("transformation failed")
+
 	ErrEvaluatingFilterExpressionWithEmptyFrame = errors.Join(ErrEvaluatingFilterExpression, errors.New("unable to apply filter on nil frame"))
 )
@@ -0,0 +1,111 @@
+package transformations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/framesql"
+)
+
+type GroupByOptions struct {
+	// By is the list of key fields. Output has one row per unique combination of the key field values
+	By []string `json:"by,omitempty"`
+	// Aggregations are evaluated for each group. Example: {"expression":"sum(price)","alias":"total"}
+	Aggregations []GroupByAggregation `json:"aggregations,omitempty"`
+}
+
+type GroupByAggregation struct {
+	Expression string `json:"expression"`
+	Alias      string `json:"alias,omitempty"`
+}
+
+// GroupBy groups the rows of each frame by the key fields and evaluates the aggregations for each group
+func GroupBy(input []*data.Frame, options GroupByOptions) ([]*data.Frame, error) {
+	output := []*data.Frame{}
+	for _, frame := range input {
+		groupedFrame, err := GetGroupByFrame(frame, options.By, options.Aggregations)
+		if err != nil {
+			return output, err
+		}
+		output = append(output, groupedFrame)
+	}
+	return output, nil
+}
+
+// GetGroupByFrame returns the frame with the key fields followed by one field per aggregation.
+// Groups are in the order of their first occurrence and null key values form their own group
+func GetGroupByFrame(frame *data.Frame, by []string, aggregations []GroupByAggregation) (*data.Frame, error) {
+	if frame == nil {
+		return frame, nil
+	}
+	keyFields := []*data.Field{}
+	for _, name := range by {
+		idx := getFieldIndex(frame, name)
+		if idx < 0 {
+			return frame, fmt.Errorf("%w. %q", ErrGroupByFieldNotFound, name)
+		}
+		keyFields = append(keyFields, frame.Fields[idx])
+	}
+	rowLen, err := frame.RowLen()
+	if err != nil {
+		return frame, err
+	}
+	// single pass to collect the rows of each group
+	groupIndex := map[string]int{}
+	groups := [][]int{}
+	for row := 0; row < rowLen; row++ {
+		key := groupKey(keyFields, row)
+		idx, ok := groupIndex[key]
+		if !ok {
+			idx = len(groups)
+			groupIndex[key] = idx
+			groups = append(groups, []int{})
+		}
+		groups[idx] = append(groups[idx], row)
+	}
+	firstRows := make([]int, len(groups))
+	for idx, rows := range groups {
+		firstRows[idx] = rows[0]
+	}
+	groupByFrame := copyRows(&data.Frame{Name: frame.Name, RefID: frame.RefID, Meta: frame.Meta, Fields: keyFields}, firstRows)
+	// frames of the groups are built once and shared by the aggregations
+	groupFrames := make([]*data.Frame, len(groups))
+	for idx, rows := range groups {
+		groupFrames[idx] = copyRows(frame, rows)
+	}
+	for _, aggregation := range aggregations {
+		if strings.TrimSpace(aggregation.Expression) == "" {
+			continue
+		}
+		alias := aggregation.Alias
+		if alias == "" {
+			alias = aggregation.Expression
+		}
+		values := make([]any, len(groups))
+		for idx, groupFrame := range groupFrames {
+			value, err := framesql.EvaluateInFrame(aggregation.Expression, groupFrame)
+			if err != nil {
+				return frame, errors.Join(ErrEvaluatingGroupByAggregation, fmt.Errorf("%s. %w", aggregation.Expression, err))
+			}
+			values[idx] = value
+		}
+		groupByFrame.Fields = append(groupByFrame.Fields, framesql.ConvertFieldValuesToField(values, alias))
+	}
+	return groupByFrame, nil
+}
+
+// groupKey returns the hash key of the key field values of the row
+func groupKey(keyFields []*data.Field, row int) string {
+	var sb strings.Builder
+	for _, field := range keyFields {
+		if value, ok := field.ConcreteAt(row); ok {
+			sb.WriteString(toString(value))
+		} else {
+			sb.WriteString("\x00null")
+		}
+		sb.WriteString("\x00")
+	}
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+package transformations_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/transformations"
+	"github.com/grafana/infinity-libs/lib/go/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupBy(t *testing.T) {
+	frame := data.NewFrame("sales",
+		data.NewField("region", nil, []*string{utils.P("us"), utils.P("eu"), utils.P("us"), nil, utils.P("us")}),
+		data.NewField("product", nil, []string{"a", "a", "b", "a", "a"}),
+		data.NewField("price", nil, []*float64{toFP(10), toFP(20), toFP(30), toFP(40), toFP(50)}),
+	).SetMeta(&data.FrameMeta{PreferredVisualizationPluginID: "table"})
+	tests := []struct {
+		name    string
+		options transformations.GroupByOptions
+		want    *data.Frame
+		wantErr error
+	}{
+		{
+			name: "single key with multiple aggregations",
+			options: transformations.GroupByOptions{
+				By:           []string{"product"},
+				Aggregations: []transformations.GroupByAggregation{{Expression: "sum(price)", Alias: "total"}, {Expression: "count(price)"}},
+			},
+			want: data.NewFrame("sales",
+				data.NewField("product", nil, []string{"a", "b"}),
+				data.NewField("total", nil, []*float64{toFP(120), toFP(30)}),
+				data.NewField("count(price)", nil, []*float64{toFP(4), toFP(1)}),
+			).SetMeta(&data.FrameMeta{PreferredVisualizationPluginID: "table"}),
+		},
+		{
+			name: "multiple keys with null key",
+			options: transformations.GroupByOptions{
+				By:           []string{"region", "product"},
+				Aggregations: []transformations.GroupByAggregation{{Expression: "max(price)", Alias: "max"}},
+			},
+			want: data.NewFrame("sales",
+				data.NewField("region", nil, []*string{utils.P("us"), utils.P("eu"), utils.P("us"), nil}),
+				data.NewField("product", nil, []string{"a", "a", "b", "a"}),
+				data.NewField("max", nil, []*float64{toFP(50), toFP(20), toFP(30), toFP(40)}),
+			).SetMeta(&data.FrameMeta{PreferredVisualizationPluginID: "table"}),
+		},
+		{
+			name:    "unknown key",
+			options: transformations.GroupByOptions{By: []string{"foo"}},
+			wantErr: transformations.ErrGroupByFieldNotFound,
+		},
+		{
+			name:    "invalid aggregation",
+			options: transformations.GroupByOptions{By: []string{"product"}, Aggregations: []transformations.GroupByAggregation{{Expression: "sum(foo)"}}},
+			wantErr: transformations.ErrEvaluatingGroupByAggregation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformations.GroupBy([]*data.Frame{frame}, tt.options)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, []*data.Frame{tt.want}, got)
+		})
+	}
+}
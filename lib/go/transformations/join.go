@@ -0,0 +1,266 @@
+package transformations
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type JoinMode string
+
+const (
+	// JoinModeInner keeps only the rows with matching keys in both frames. Default
+	JoinModeInner JoinMode = "inner"
+	// JoinModeLeft keeps all the rows of the left frame
+	JoinModeLeft JoinMode = "left"
+	// JoinModeRight keeps all the rows of the right frame
+	JoinModeRight JoinMode = "right"
+	// JoinModeOuter keeps all the rows of both frames
+	JoinModeOuter JoinMode = "outer"
+)
+
+// JoinCollisionPolicy decides how the non key fields with same name in both frames are handled
+type JoinCollisionPolicy string
+
+const (
+	// JoinCollisionRename keeps both fields and suffixes the right field name with the frame name or index. Default
+	JoinCollisionRename JoinCollisionPolicy = "rename"
+	// JoinCollisionKeepLeft keeps the left field and drops the right field
+	JoinCollisionKeepLeft JoinCollisionPolicy = "keepLeft"
+	// JoinCollisionKeepRight keeps the right field and drops the left field
+	JoinCollisionKeepRight JoinCollisionPolicy = "keepRight"
+	// JoinCollisionError fails the join
+	JoinCollisionError JoinCollisionPolicy = "error"
+)
+
+type JoinOptions struct {
+	// Mode is the join mode. `inner` | `left` | `right` | `outer`. Defaults to inner
+	Mode JoinMode `json:"mode,omitempty"`
+	// By is the list of key fields. Key fields must exist in all the frames
+	By []string `json:"by,omitempty"`
+	// OnCollision is the name collision policy of the non key fields. `rename` | `keepLeft` | `keepRight` | `error`. Defaults to rename.
+	// Right fields named same as the key fields never replace the key fields. They are dropped with `keepLeft` and `keepRight`
+	OnCollision JoinCollisionPolicy `json:"onCollision,omitempty"`
+}
+
+// Join joins the frames on the key fields into a single frame. More than two frames are joined from left to right.
+// Output has the key fields followed by the non key fields of the frames. Rows with null keys never match.
+// Fields which can be missing for the unmatched rows are converted to nullable fields. Input frames are not modified
+func Join(input []*data.Frame, options JoinOptions) (*data.Frame, error) {
+	if len(input) < 1 {
+		return nil, ErrJoinTransformationNoFrameSupplied
+	}
+	if len(options.By) == 0 {
+		return nil, errors.Join(ErrInvalidJoinOptions, errors.New("key fields are required"))
+	}
+	if !slices.Contains([]JoinMode{"", JoinModeInner, JoinModeLeft, JoinModeRight, JoinModeOuter}, options.Mode) {
+		return nil, errors.Join(ErrInvalidJoinOptions, fmt.Errorf("invalid join mode %q", options.Mode))
+	}
+	if !slices.Contains([]JoinCollisionPolicy{"", JoinCollisionRename, JoinCollisionKeepLeft, JoinCollisionKeepRight, JoinCollisionError}, options.OnCollision) {
+		return nil, errors.Join(ErrInvalidJoinOptions, fmt.Errorf("invalid collision policy %q", options.OnCollision))
+	}
+	out := input[0]
+	if out == nil {
+		return nil, ErrJoinTransformationNoFrameSupplied
+	}
+	if _, err := joinKeyFields(out, options.By); err != nil {
+		return nil, err
+	}
+	for idx, frame := range input[1:] {
+		if frame == nil {
+			continue
+		}
+		joined, err := joinFrames(out, frame, idx+1, options)
+		if err != nil {
+			return nil, err
+		}
+		out = joined
+	}
+	if out == input[0] {
+		// no frame joined. Example: the frames other than the first are nil
+		out = copyRows(out, rowRange(out.Rows()))
+	}
+	return out, nil
+}
+
+// joinFrames joins the left frame with the right frame. index is the index of the right frame in the input
+func joinFrames(left, right *data.Frame, index int, options JoinOptions) (*data.Frame, error) {
+	leftKeys, err := joinKeyFields(left, options.By)
+	if err != nil {
+		return nil, err
+	}
+	rightKeys, err := joinKeyFields(right, options.By)
+	if err != nil {
+		return nil, err
+	}
+	for i := range leftKeys {
+		if left.Fields[leftKeys[i]].Type().NonNullableType() != right.Fields[rightKeys[i]].Type().NonNullableType() {
+			return nil, fmt.Errorf("%w. %q", ErrJoinFieldTypeMismatch, options.By[i])
+		}
+	}
+	leftRows, rightRows := joinRows(left, right, leftKeys, rightKeys, options.Mode)
+	out := &data.Frame{Name: left.Name, RefID: left.RefID, Meta: copyMeta(left.Meta), Fields: []*data.Field{}}
+	// key fields take the value from the right frame for the unmatched right rows
+	for i := range leftKeys {
+		leftField, rightField := left.Fields[leftKeys[i]], right.Fields[rightKeys[i]]
+		fieldType := leftField.Type()
+		if rightField.Nullable() {
+			fieldType = fieldType.NullableType()
+		}
+		field := newFieldLike(leftField, fieldType, len(leftRows))
+		for row := range leftRows {
+			if leftRows[row] >= 0 {
+				setConcrete(field, row, leftField, leftRows[row])
+				continue
+			}
+			setConcrete(field, row, rightField, rightRows[row])
+		}
+		out.Fields = append(out.Fields, field)
+	}
+	leftFields := nonKeyFields(left, leftKeys)
+	rightFields := nonKeyFields(right, rightKeys)
+	rightNames := map[string]bool{}
+	for _, f := range rightFields {
+		rightNames[f.Name] = true
+	}
+	// key fields are always kept. Right fields with the name of a key field are never kept in place of the key field
+	keyNames := map[string]bool{}
+	for _, f := range out.Fields {
+		keyNames[f.Name] = true
+	}
+	names := maps.Clone(keyNames)
+	for _, f := range leftFields {
+		if rightNames[f.Name] && !keyNames[f.Name] && options.OnCollision == JoinCollisionKeepRight {
+			continue
+		}
+		out.Fields = append(out.Fields, joinedField(f, leftRows, options.Mode == JoinModeRight || options.Mode == JoinModeOuter))
+		names[f.Name] = true
+	}
+	for _, f := range rightFields {
+		field := joinedField(f, rightRows, options.Mode == JoinModeLeft || options.Mode == JoinModeOuter)
+		if names[f.Name] {
+			switch options.OnCollision {
+			case JoinCollisionKeepLeft:
+				continue
+			case JoinCollisionError:
+				return nil, fmt.Errorf("%w. %q", ErrJoinFieldNameCollision, f.Name)
+			case JoinCollisionKeepRight:
+				if keyNames[f.Name] {
+					continue
+				}
+			default:
+				suffix := right.Name
+				if strings.TrimSpace(suffix) == "" {
+					suffix = fmt.Sprintf("%d", index)
+				}
+				field.Name = fmt.Sprintf("%s (%s)", f.Name, suffix)
+			}
+		}
+		out.Fields = append(out.Fields, field)
+	}
+	return out, nil
+}
+
+// joinRows returns the pairs of the left and right row indexes of the joined rows. -1 denotes the missing row
+func joinRows(left, right *data.Frame, leftKeys, rightKeys []int, mode JoinMode) ([]int, []int) {
+	leftRows, rightRows := []int{}, []int{}
+	if mode == JoinModeRight {
+		index := joinIndex(left, leftKeys)
+		for r := 0; r < right.Rows(); r++ {
+			matches := index[joinKey(right, rightKeys, r)]
+			if len(matches) == 0 {
+				leftRows, rightRows = append(leftRows, -1), append(rightRows, r)
+			}
+			for _, l := range matches {
+				leftRows, rightRows = append(leftRows, l), append(rightRows, r)
+			}
+		}
+		return leftRows, rightRows
+	}
+	index := joinIndex(right, rightKeys)
+	matched := make([]bool, right.Rows())
+	for l := 0; l < left.Rows(); l++ {
+		matches := index[joinKey(left, leftKeys, l)]
+		if len(matches) == 0 && mode != "" && mode != JoinModeInner {
+			leftRows, rightRows = append(leftRows, l), append(rightRows, -1)
+		}
+		for _, r := range matches {
+			leftRows, rightRows = append(leftRows, l), append(rightRows, r)
+			matched[r] = true
+		}
+	}
+	if mode == JoinModeOuter {
+		for r := range matched {
+			if !matched[r] {
+				leftRows, rightRows = append(leftRows, -1), append(rightRows, r)
+			}
+		}
+	}
+	return leftRows, rightRows
+}
+
+// joinIndex returns the row indexes of the frame by the key. Rows with null keys are not indexed
+func joinIndex(frame *data.Frame, keys []int) map[string][]int {
+	index := map[string][]int{}
+	for row := 0; row < frame.Rows(); row++ {
+		if key := joinKey(frame, keys, row); key != "" {
+			index[key] = append(index[key], row)
+		}
+	}
+	return index
+}
+
+// joinKey returns the hash key of the key fields of the row. Returns empty string when any of the key is null
+func joinKey(frame *data.Frame, keys []int, row int) string {
+	var sb strings.Builder
+	for _, idx := range keys {
+		value, ok := frame.Fields[idx].ConcreteAt(row)
+		if !ok {
+			return ""
+		}
+		sb.WriteString(toString(value))
+		sb.WriteString("\x00")
+	}
+	return sb.String()
+}
+
+func joinKeyFields(frame *data.Frame, by []string) ([]int, error) {
+	keys := []int{}
+	for _, name := range by {
+		idx := getFieldIndex(frame, name)
+		if idx < 0 {
+			return nil, fmt.Errorf("%w. %q", ErrJoinFieldNotFound, name)
+		}
+		keys = append(keys, idx)
+	}
+	return keys, nil
+}
+
+func nonKeyFields(frame *data.Frame, keys []int) []*data.Field {
+	fields := []*data.Field{}
+	for idx, field := range frame.Fields {
+		if !slices.Contains(keys, idx) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// joinedField returns the field with the values of the given rows. Field is nullable when the rows can be missing
+func joinedField(field *data.Field, rows []int, missing bool) *data.Field {
+	fieldType := field.Type()
+	if missing {
+		fieldType = fieldType.NullableType()
+	}
+	out := newFieldLike(field, fieldType, len(rows))
+	for i, row := range rows {
+		if row >= 0 {
+			setConcrete(out, i, field, row)
+		}
+	}
+	return out
+}
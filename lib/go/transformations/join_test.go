@@ -0,0 +1,158 @@
+package transformations_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/transformations"
+	"github.com/grafana/infinity-libs/lib/go/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoin(t *testing.T) {
+	inventory := data.NewFrame("inventory",
+		data.NewField("host", nil, []string{"a", "b", "c"}),
+		data.NewField("os", nil, []string{"linux", "windows", "linux"}),
+		data.NewField("updated", nil, []int64{1, 2, 3}),
+	)
+	metrics := data.NewFrame("metrics",
+		data.NewField("host", nil, []*string{utils.P("b"), utils.P("a"), utils.P("d"), nil, utils.P("a")}),
+		data.NewField("cpu", nil, []float64{0.2, 0.1, 0.4, 0.5, 0.3}),
+		data.NewField("updated", nil, []int64{10, 20, 30, 40, 50}),
+	)
+	tests := []struct {
+		name    string
+		frames  []*data.Frame
+		options transformations.JoinOptions
+		want    *data.Frame
+		wantErr error
+	}{
+		{
+			name:    "inner join",
+			options: transformations.JoinOptions{By: []string{"host"}},
+			want: data.NewFrame("inventory",
+				data.NewField("host", nil, []*string{utils.P("a"), utils.P("a"), utils.P("b")}),
+				data.NewField("os", nil, []string{"linux", "linux", "windows"}),
+				data.NewField("updated", nil, []int64{1, 1, 2}),
+				data.NewField("cpu", nil, []float64{0.1, 0.3, 0.2}),
+				data.NewField("updated (metrics)", nil, []int64{20, 50, 10}),
+			),
+		},
+		{
+			name:    "left join",
+			options: transformations.JoinOptions{Mode: transformations.JoinModeLeft, By: []string{"host"}, OnCollision: transformations.JoinCollisionKeepLeft},
+			want: data.NewFrame("inventory",
+				data.NewField("host", nil, []*string{utils.P("a"), utils.P("a"), utils.P("b"), utils.P("c")}),
+				data.NewField("os", nil, []string{"linux", "linux", "windows", "linux"}),
+				data.NewField("updated", nil, []int64{1, 1, 2, 3}),
+				data.NewField("cpu", nil, []*float64{utils.P(0.1), utils.P(0.3), utils.P(0.2), nil}),
+			),
+		},
+		{
+			name:    "right join",
+			options: transformations.JoinOptions{Mode: transformations.JoinModeRight, By: []string{"host"}, OnCollision: transformations.JoinCollisionKeepRight},
+			want: data.NewFrame("inventory",
+				data.NewField("host", nil, []*string{utils.P("b"), utils.P("a"), utils.P("d"), nil, utils.P("a")}),
+				data.NewField("os", nil, []*string{utils.P("windows"), utils.P("linux"), nil, nil, utils.P("linux")}),
+				data.NewField("cpu", nil, []float64{0.2, 0.1, 0.4, 0.5, 0.3}),
+				data.NewField("updated", nil, []int64{10, 20, 30, 40, 50}),
+			),
+		},
+		{
+			name:    "full outer join",
+			options: transformations.JoinOptions{Mode: transformations.JoinModeOuter, By: []string{"host"}, OnCollision: transformations.JoinCollisionKeepLeft},
+			want: data.NewFrame("inventory",
+				data.NewField("host", nil, []*string{utils.P("a"), utils.P("a"), utils.P("b"), utils.P("c"), utils.P("d"), nil}),
+				data.NewField("os", nil, []*string{utils.P("linux"), utils.P("linux"), utils.P("windows"), utils.P("linux"), nil, nil}),
+				data.NewField("updated", nil, []*int64{utils.P(int64(1)), utils.P(int64(1)), utils.P(int64(2)), utils.P(int64(3)), nil, nil}),
+				data.NewField("cpu", nil, []*float64{utils.P(0.1), utils.P(0.3), utils.P(0.2), nil, utils.P(0.4), utils.P(0.5)}),
+			),
+		},
+		{
+			name: "multiple frames and keys",
+			frames: []*data.Frame{
+				data.NewFrame("", data.NewField("host", nil, []string{"a", "a"}), data.NewField("region", nil, []string{"us", "eu"}), data.NewField("value", nil, []int64{1, 2})),
+				data.NewFrame("", data.NewField("host", nil, []string{"a"}), data.NewField("region", nil, []string{"eu"}), data.NewField("value", nil, []int64{3})),
+				data.NewFrame("", data.NewField("region", nil, []string{"eu"}), data.NewField("host", nil, []string{"a"}), data.NewField("value", nil, []int64{4})),
+			},
+			options: transformations.JoinOptions{By: []string{"host", "region"}},
+			want: data.NewFrame("",
+				data.NewField("host", nil, []string{"a"}),
+				data.NewField("region", nil, []string{"eu"}),
+				data.NewField("value", nil, []int64{2}),
+				data.NewField("value (1)", nil, []int64{3}),
+				data.NewField("value (2)", nil, []int64{4}),
+			),
+		},
+		{
+			name:    "right field with key name is dropped with keep right",
+			options: transformations.JoinOptions{By: []string{"host"}, OnCollision: transformations.JoinCollisionKeepRight},
+			frames: []*data.Frame{
+				data.NewFrame("", data.NewField("host", nil, []string{"a"}), data.NewField("os", nil, []string{"linux"})),
+				data.NewFrame("", data.NewField("Host", nil, []string{"a"}), data.NewField("host", nil, []string{"a.local"})),
+			},
+			want: data.NewFrame("",
+				data.NewField("host", nil, []string{"a"}),
+				data.NewField("os", nil, []string{"linux"}),
+			),
+		},
+		{
+			name:    "right field with key name is renamed",
+			options: transformations.JoinOptions{By: []string{"host"}},
+			frames: []*data.Frame{
+				data.NewFrame("", data.NewField("host", nil, []string{"a"})),
+				data.NewFrame("hosts", data.NewField("Host", nil, []string{"a"}), data.NewField("host", nil, []string{"a.local"})),
+			},
+			want: data.NewFrame("",
+				data.NewField("host", nil, []string{"a"}),
+				data.NewField("host (hosts)", nil, []string{"a.local"}),
+			),
+		},
+		{
+			name:    "name collision",
+			options: transformations.JoinOptions{By: []string{"host"}, OnCollision: transformations.JoinCollisionError},
+			wantErr: transformations.ErrJoinFieldNameCollision,
+		},
+		{
+			name:    "unknown key",
+			options: transformations.JoinOptions{By: []string{"foo"}},
+			wantErr: transformations.ErrJoinFieldNotFound,
+		},
+		{
+			name:    "key type mismatch",
+			options: transformations.JoinOptions{By: []string{"updated"}},
+			frames:  []*data.Frame{inventory, data.NewFrame("", data.NewField("updated", nil, []float64{1}))},
+			wantErr: transformations.ErrJoinFieldTypeMismatch,
+		},
+		{
+			name:    "invalid mode",
+			options: transformations.JoinOptions{Mode: "cross", By: []string{"host"}},
+			wantErr: transformations.ErrInvalidJoinOptions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames := tt.frames
+			if frames == nil {
+				frames = []*data.Frame{inventory, metrics}
+			}
+			got, err := transformations.Join(frames, tt.options)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+	t.Run("single frame with nil frames is copied", func(t *testing.T) {
+		got, err := transformations.Join([]*data.Frame{inventory, nil}, transformations.JoinOptions{By: []string{"host"}})
+		require.Nil(t, err)
+		require.NotSame(t, inventory, got)
+		require.Equal(t, inventory, got)
+		got.AppendNotices(data.Notice{Text: "joined"})
+		require.Nil(t, inventory.Meta)
+	})
+	require.Equal(t, 3, inventory.Rows())
+	require.Equal(t, 3, len(inventory.Fields))
+}
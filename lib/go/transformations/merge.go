@@ -1,59 +1,75 @@
 package transformations
 
 import (
+	"slices"
+
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 type MergeFramesOptions struct {
 }
 
-// Merge transformation used to merge multiple dataframe of same structure into single frame
-// Requirement: Fields length should be same across the frame
-// Requirement: Field names for all the input frames must be same
-// Requirement: Fields must not have labels. Any labels for the fields will be ignored
-// Requirement: Field must be in same order. (??)
+// Merge transformation used to merge multiple dataframe into single frame
+// Output fields are the union of the input fields matched by the field name, type and labels in the order of their first occurrence
+// Fields missing in some of the frames are filled with null values and converted to nullable fields
+// Name, RefID and Meta of the output are taken from the first frame. Input frames are not modified
 // Ref: https://github.com/grafana/grafana/blob/v9.5.2/packages/grafana-data/src/transformations/transformers/merge.ts
 func Merge(inputFrames []*data.Frame, option MergeFramesOptions) (*data.Frame, error) {
-	if len(inputFrames) < 1 {
+	frames := []*data.Frame{}
+	for _, frame := range inputFrames {
+		if frame != nil {
+			frames = append(frames, frame)
+		}
+	}
+	if len(frames) < 1 {
 		return nil, ErrMergeTransformationNoFrameSupplied
 	}
-	outFrame := inputFrames[0]
-	for frameId, frame := range inputFrames {
-		if frameId == 0 {
-			continue
-		}
-		if len(outFrame.Fields) != len(frame.Fields) {
-			return nil, ErrMergeTransformationDifferentFields
+	type mergeField struct {
+		field    *data.Field
+		nullable bool
+		sources  []*data.Field
+	}
+	fields := []*mergeField{}
+	fieldIndex := map[string][]int{}
+	rowsCount := 0
+	for frameIdx, frame := range frames {
+		rows, err := frame.RowLen()
+		if err != nil {
+			return nil, err
 		}
-		for fi, f := range frame.Fields {
-			if f.Name != outFrame.Fields[fi].Name {
-				return nil, ErrMergeTransformationDifferentFieldNames
-			}
-			if f.Type() != outFrame.Fields[fi].Type() {
-				return nil, ErrMergeTransformationDifferentFieldTypes
+		rowsCount += rows
+		for _, f := range frame.Fields {
+			key := f.Name + "\x00" + f.Type().NonNullableType().ItemTypeString() + "\x00" + f.Labels.String()
+			// same field can appear more than once in a frame
+			idx := slices.IndexFunc(fieldIndex[key], func(i int) bool { return fields[i].sources[frameIdx] == nil })
+			if idx < 0 {
+				idx = len(fields)
+				fieldIndex[key] = append(fieldIndex[key], idx)
+				fields = append(fields, &mergeField{field: f, nullable: frameIdx > 0, sources: make([]*data.Field, len(frames))})
+			} else {
+				idx = fieldIndex[key][idx]
 			}
+			fields[idx].sources[frameIdx] = f
+			fields[idx].nullable = fields[idx].nullable || f.Nullable()
+		}
+	}
+	outFrame := &data.Frame{Name: frames[0].Name, RefID: frames[0].RefID, Meta: copyMeta(frames[0].Meta), Fields: make([]*data.Field, 0, len(fields))}
+	for _, mf := range fields {
+		fieldType := mf.field.Type()
+		if mf.nullable || slices.Contains(mf.sources, nil) {
+			fieldType = fieldType.NullableType()
 		}
-		for rowId := 0; rowId < frame.Rows(); rowId++ {
-			fieldValues := []any{}
-			for fieldId := range frame.Fields {
-				fieldValues = append(fieldValues, frame.At(fieldId, rowId))
+		outField := newFieldLike(mf.field, fieldType, rowsCount)
+		offset := 0
+		for frameIdx, frame := range frames {
+			if source := mf.sources[frameIdx]; source != nil {
+				for row := 0; row < source.Len(); row++ {
+					setConcrete(outField, offset+row, source, row)
+				}
 			}
-			outFrame.AppendRow(fieldValues...)
+			offset += frame.Rows()
 		}
+		outFrame.Fields = append(outFrame.Fields, outField)
 	}
-	// // TODO: Perform proper merging. Check for field names match, field length, field type, field order, field labels etc
-	// // https://github.com/grafana/grafana/blob/v9.5.2/packages/grafana-data/src/transformations/transformers/merge.ts
-	// emptyFrame := data.NewFrame(firstFrame.Name).SetMeta(firstFrame.Meta)
-	// emptyFrame.RefID = firstFrame.RefID
-	// for _, frame := range input {
-	// 	for _, field := range frame.Fields {
-	// 		if !fieldExists(emptyFrame, field) {
-	// 			newField := data.NewFieldFromFieldType(field.Type(), 0)
-	// 			newField.Name = field.Name
-	// 			emptyFrame.Fields = append(emptyFrame.Fields, newField)
-	// 		}
-	// 	}
-	// }
-	// //
 	return outFrame, nil
 }
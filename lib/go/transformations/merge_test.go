@@ -0,0 +1,82 @@
+package transformations_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/transformations"
+	"github.com/grafana/infinity-libs/lib/go/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		frames  []*data.Frame
+		want    *data.Frame
+		wantErr error
+	}{
+		{
+			name:    "no frames",
+			wantErr: transformations.ErrMergeTransformationNoFrameSupplied,
+		},
+		{
+			name: "same fields",
+			frames: []*data.Frame{
+				data.NewFrame("a", data.NewField("name", nil, []string{"foo"}), data.NewField("age", nil, []int64{1})).SetMeta(&data.FrameMeta{PreferredVisualizationPluginID: "table"}),
+				data.NewFrame("b", data.NewField("name", nil, []string{"bar"}), data.NewField("age", nil, []int64{2})),
+			},
+			want: data.NewFrame("a", data.NewField("name", nil, []string{"foo", "bar"}), data.NewField("age", nil, []int64{1, 2})).SetMeta(&data.FrameMeta{PreferredVisualizationPluginID: "table"}),
+		},
+		{
+			name: "different field order",
+			frames: []*data.Frame{
+				data.NewFrame("a", data.NewField("name", nil, []string{"foo"}), data.NewField("age", nil, []int64{1})),
+				data.NewFrame("b", data.NewField("age", nil, []*int64{utils.P(int64(2))}), data.NewField("name", nil, []string{"bar"})),
+			},
+			want: data.NewFrame("a", data.NewField("name", nil, []string{"foo", "bar"}), data.NewField("age", nil, []*int64{utils.P(int64(1)), utils.P(int64(2))})),
+		},
+		{
+			name: "different fields",
+			frames: []*data.Frame{
+				data.NewFrame("a", data.NewField("name", nil, []string{"foo", "baz"}), data.NewField("age", nil, []int64{1, 3})),
+				nil,
+				data.NewFrame("b", data.NewField("name", nil, []string{"bar"}), data.NewField("city", nil, []string{"x"})),
+			},
+			want: data.NewFrame("a",
+				data.NewField("name", nil, []string{"foo", "baz", "bar"}),
+				data.NewField("age", nil, []*int64{utils.P(int64(1)), utils.P(int64(3)), nil}),
+				data.NewField("city", nil, []*string{nil, nil, utils.P("x")}),
+			),
+		},
+		{
+			name: "same name with different types and labels",
+			frames: []*data.Frame{
+				data.NewFrame("a", data.NewField("value", data.Labels{"host": "a"}, []float64{1})),
+				data.NewFrame("b", data.NewField("value", data.Labels{"host": "b"}, []float64{2}), data.NewField("value", data.Labels{"host": "a"}, []string{"x"})),
+			},
+			want: data.NewFrame("a",
+				data.NewField("value", data.Labels{"host": "a"}, []*float64{utils.P(1.0), nil}),
+				data.NewField("value", data.Labels{"host": "b"}, []*float64{nil, utils.P(2.0)}),
+				data.NewField("value", data.Labels{"host": "a"}, []*string{nil, utils.P("x")}),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformations.Merge(tt.frames, transformations.MergeFramesOptions{})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+	t.Run("input frames are not modified", func(t *testing.T) {
+		first := data.NewFrame("a", data.NewField("name", nil, []string{"foo"}))
+		_, err := transformations.Merge([]*data.Frame{first, data.NewFrame("b", data.NewField("name", nil, []string{"bar"}))}, transformations.MergeFramesOptions{})
+		require.Nil(t, err)
+		require.Equal(t, data.NewFrame("a", data.NewField("name", nil, []string{"foo"})), first)
+	})
+}
@@ -0,0 +1,202 @@
+package transformations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// TransformationFunc applies the transformation to the frames. options are the raw json options of the pipeline step
+type TransformationFunc func(input []*data.Frame, options json.RawMessage) ([]*data.Frame, error)
+
+// ErrorPolicy decides what happens to the pipeline when a step fails
+type ErrorPolicy string
+
+const (
+	// ErrorPolicyFail stops the pipeline and returns the error. Default
+	ErrorPolicyFail ErrorPolicy = "fail"
+	// ErrorPolicySkip ignores the failed step and continues with the frames of the previous step
+	ErrorPolicySkip ErrorPolicy = "skip"
+	// ErrorPolicyNotice is same as skip and additionally attaches the error as warning notice to the frames
+	ErrorPolicyNotice ErrorPolicy = "notice"
+)
+
+// PipelineStep is a single transformation of the pipeline.
+// Example: {"type":"filterExpression","options":{"expression":"age > 18"},"onError":"skip"}
+type PipelineStep struct {
+	// Type is the name of the registered transformation. Grafana's `id` property is also accepted
+	Type string `json:"type,omitempty"`
+	// ID is the Grafana name of the type property
+	ID string `json:"id,omitempty"`
+	// Options are decoded by the transformation
+	Options json.RawMessage `json:"options,omitempty"`
+	// Disabled steps are not applied
+	Disabled bool `json:"disabled,omitempty"`
+	// OnError is the error policy of the step. `fail` | `skip` | `notice`. Defaults to fail
+	OnError ErrorPolicy `json:"onError,omitempty"`
+}
+
+// Pipeline is the list of transformations applied in order
+type Pipeline []PipelineStep
+
+// ParsePipeline decodes the pipeline from json. Example: [{"type":"filterExpression","options":{...}},{"type":"limit","options":{...}}]
+func ParsePipeline(input []byte) (Pipeline, error) {
+	pipeline := Pipeline{}
+	if err := json.Unmarshal(input, &pipeline); err != nil {
+		return nil, errors.Join(ErrInvalidPipeline, err)
+	}
+	for idx, step := range pipeline {
+		if step.name() == "" {
+			return nil, fmt.Errorf("%w. step %d. transformation type is required", ErrInvalidPipeline, idx)
+		}
+		if !slices.Contains([]ErrorPolicy{"", ErrorPolicyFail, ErrorPolicySkip, ErrorPolicyNotice}, step.OnError) {
+			return nil, fmt.Errorf("%w. step %d. invalid error policy %q", ErrInvalidPipeline, idx, step.OnError)
+		}
+	}
+	return pipeline, nil
+}
+
+// Apply applies the pipeline using the built-in transformations. See Registry.Apply
+func (p Pipeline) Apply(input []*data.Frame) ([]*data.Frame, error) {
+	return defaultRegistry.Apply(input, p)
+}
+
+func (step PipelineStep) name() string {
+	if step.Type != "" {
+		return step.Type
+	}
+	return step.ID
+}
+
+// Registry holds the transformations available to the pipeline. Registry is safe for concurrent use.
+type Registry struct {
+	mu              sync.RWMutex
+	transformations map[string]TransformationFunc
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{transformations: map[string]TransformationFunc{}}
+}
+
+// NewDefaultRegistry returns a registry pre-populated with the built-in transformations
+func NewDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register("filterExpression", NewTransformation(FilterExpression))
+	r.Register("limit", NewTransformation(Limit))
+	r.Register("merge", NewTransformation(func(input []*data.Frame, options MergeFramesOptions) ([]*data.Frame, error) {
+		frame, err := Merge(input, options)
+		if err != nil {
+			return nil, err
+		}
+		return []*data.Frame{frame}, nil
+	}))
+	r.Register("computedColumns", NewTransformation(ComputedColumns))
+	r.Register("summarize", NewTransformation(Summarize))
+	r.Register("sortBy", NewTransformation(SortBy))
+	r.Register("groupBy", NewTransformation(GroupBy))
+	r.Register("join", NewTransformation(func(input []*data.Frame, options JoinOptions) ([]*data.Frame, error) {
+		frame, err := Join(input, options)
+		if err != nil {
+			return nil, err
+		}
+		return []*data.Frame{frame}, nil
+	}))
+	r.Register("pivot", NewTransformation(Pivot))
+	r.Register("unpivot", NewTransformation(Unpivot))
+	r.Register("melt", NewTransformation(Unpivot))
+	r.Register("seriesToRows", NewTransformation(SeriesToRows))
+	r.Register("rowsToFields", NewTransformation(RowsToFields))
+	return r
+}
+
+// defaultRegistry holds the built-in transformations used by Pipeline.Apply
+var defaultRegistry = NewDefaultRegistry()
+
+// NewTransformation wraps the transformation with typed options into TransformationFunc. Options are decoded from the json options of the step
+func NewTransformation[T any](fn func(input []*data.Frame, options T) ([]*data.Frame, error)) TransformationFunc {
+	return func(input []*data.Frame, rawOptions json.RawMessage) ([]*data.Frame, error) {
+		var options T
+		if len(rawOptions) > 0 && string(rawOptions) != "null" {
+			if err := json.Unmarshal(rawOptions, &options); err != nil {
+				return nil, errors.Join(ErrInvalidTransformationOptions, err)
+			}
+		}
+		return fn(input, options)
+	}
+}
+
+// Register registers the transformation. Registering an existing name replaces the transformation
+func (r *Registry) Register(name string, fn TransformationFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transformations[name] = fn
+}
+
+// Names returns the sorted names of the registered transformations
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := []string{}
+	for name := range r.transformations {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Apply applies the enabled steps of the pipeline in order. Output frames of each step are the input frames of the next step.
+// Failed steps are handled as per the error policy of the step.
+// Each step receives a copy of the frames so that the input frames are not modified and the failed steps leave no partial changes
+func (r *Registry) Apply(input []*data.Frame, pipeline Pipeline) ([]*data.Frame, error) {
+	frames := input
+	for idx, step := range pipeline {
+		if step.Disabled {
+			continue
+		}
+		output, err := r.applyStep(copyFrames(frames), step)
+		if err == nil {
+			frames = output
+			continue
+		}
+		err = fmt.Errorf("step %d (%s). %w", idx, step.name(), err)
+		switch step.OnError {
+		case ErrorPolicySkip:
+		case ErrorPolicyNotice:
+			frames = copyFrames(frames)
+			for _, frame := range frames {
+				if frame != nil {
+					frame.AppendNotices(data.Notice{Severity: data.NoticeSeverityWarning, Text: fmt.Sprintf("transformation skipped. %s", err.Error())})
+				}
+			}
+		default:
+			return nil, errors.Join(ErrTransformationFailed, err)
+		}
+	}
+	return frames, nil
+}
+
+func (r *Registry) applyStep(frames []*data.Frame, step PipelineStep) ([]*data.Frame, error) {
+	r.mu.RLock()
+	fn, ok := r.transformations[step.name()]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownTransformation
+	}
+	return fn(frames, step.Options)
+}
+
+// copyFrames returns the copies of the frames. nil frames are kept as it is
+func copyFrames(frames []*data.Frame) []*data.Frame {
+	out := make([]*data.Frame, len(frames))
+	for idx, frame := range frames {
+		if frame != nil {
+			out[idx] = copyRows(frame, rowRange(frame.Rows()))
+		}
+	}
+	return out
+}
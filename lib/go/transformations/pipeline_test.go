@@ -0,0 +1,152 @@
+package transformations_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/transformations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline(t *testing.T) {
+	usersFrame := func() *data.Frame {
+		return data.NewFrame("users",
+			data.NewField("name", nil, []string{"foo", "bar", "baz", "qux"}),
+			data.NewField("age", nil, []int64{10, 20, 30, 40}),
+		)
+	}
+	tests := []struct {
+		name        string
+		pipeline    string
+		want        []*data.Frame
+		wantNotices []string
+		wantErr     error
+	}{
+		{
+			name:     "empty pipeline",
+			pipeline: `[]`,
+			want:     []*data.Frame{usersFrame()},
+		},
+		{
+			name:     "steps applied in order",
+			pipeline: `[{"type":"filterExpression","options":{"expression":"age > 10"}},{"type":"limit","options":{"limitField":2}}]`,
+			want: []*data.Frame{data.NewFrame("users",
+				data.NewField("name", nil, []string{"bar", "baz"}),
+				data.NewField("age", nil, []int64{20, 30}),
+			)},
+		},
+		{
+			name:     "disabled step",
+			pipeline: `[{"type":"filterExpression","options":{"expression":"age > 10"},"disabled":true},{"id":"limit","options":{"limitField":1}}]`,
+			want: []*data.Frame{data.NewFrame("users",
+				data.NewField("name", nil, []string{"foo"}),
+				data.NewField("age", nil, []int64{10}),
+			)},
+		},
+		{
+			name:     "summarize",
+			pipeline: `[{"type":"summarize","options":{"expression":"sum(age)","alias":"total"}}]`,
+			want:     []*data.Frame{data.NewFrame("users", data.NewField("total", nil, []*float64{toFP(100)}))},
+		},
+		{
+			name:     "failed step",
+			pipeline: `[{"type":"filterExpression","options":{"expression":"age >"}},{"type":"limit","options":{"limitField":1}}]`,
+			wantErr:  transformations.ErrTransformationFailed,
+		},
+		{
+			name:     "unknown step",
+			pipeline: `[{"type":"unknown"}]`,
+			wantErr:  transformations.ErrUnknownTransformation,
+		},
+		{
+			name:     "invalid options",
+			pipeline: `[{"type":"limit","options":{"limitField":"one"}}]`,
+			wantErr:  transformations.ErrInvalidTransformationOptions,
+		},
+		{
+			name:     "failed step with skip policy",
+			pipeline: `[{"type":"filterExpression","options":{"expression":"age >"},"onError":"skip"},{"type":"limit","options":{"limitField":1}}]`,
+			want: []*data.Frame{data.NewFrame("users",
+				data.NewField("name", nil, []string{"foo"}),
+				data.NewField("age", nil, []int64{10}),
+			)},
+		},
+		{
+			name:        "failed step with notice policy",
+			pipeline:    `[{"type":"unknown","onError":"notice"},{"type":"limit","options":{"limitField":1}}]`,
+			wantNotices: []string{"transformation skipped. step 0 (unknown). unknown transformation"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := transformations.ParsePipeline([]byte(tt.pipeline))
+			require.Nil(t, err)
+			got, err := pipeline.Apply([]*data.Frame{usersFrame()})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			if tt.wantNotices != nil {
+				require.Len(t, got, 1)
+				require.NotNil(t, got[0].Meta)
+				notices := []string{}
+				for _, notice := range got[0].Meta.Notices {
+					notices = append(notices, notice.Text)
+				}
+				require.Equal(t, tt.wantNotices, notices)
+				return
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+	t.Run("invalid pipeline", func(t *testing.T) {
+		for _, pipeline := range []string{`{}`, `[{"options":{}}]`, `[{"type":"limit","onError":"retry"}]`} {
+			_, err := transformations.ParsePipeline([]byte(pipeline))
+			require.ErrorIs(t, err, transformations.ErrInvalidPipeline, pipeline)
+		}
+	})
+	t.Run("custom transformation", func(t *testing.T) {
+		registry := transformations.NewDefaultRegistry()
+		registry.Register("rename", transformations.NewTransformation(func(input []*data.Frame, options struct {
+			Name string `json:"name"`
+		}) ([]*data.Frame, error) {
+			if options.Name == "" {
+				return nil, errors.New("name is required")
+			}
+			for _, frame := range input {
+				frame.Name = options.Name
+			}
+			return input, nil
+		}))
+		require.Contains(t, registry.Names(), "rename")
+		got, err := registry.Apply([]*data.Frame{usersFrame()}, transformations.Pipeline{
+			{Type: "rename", Options: json.RawMessage(`{"name":"people"}`)},
+		})
+		require.Nil(t, err)
+		require.Equal(t, "people", got[0].Name)
+	})
+	t.Run("input frames are not modified", func(t *testing.T) {
+		registry := transformations.NewDefaultRegistry()
+		// truncates the frames in place and then fails
+		registry.Register("truncate", func(input []*data.Frame, options json.RawMessage) ([]*data.Frame, error) {
+			for _, frame := range input {
+				for _, field := range frame.Fields {
+					field.Delete(0)
+				}
+			}
+			return nil, errors.New("truncate failed")
+		})
+		input := []*data.Frame{usersFrame()}
+		got, err := registry.Apply(input, transformations.Pipeline{
+			{Type: "truncate", OnError: transformations.ErrorPolicyNotice},
+			{Type: "truncate", OnError: transformations.ErrorPolicySkip},
+		})
+		require.Nil(t, err)
+		require.Equal(t, []*data.Frame{usersFrame()}, input)
+		require.Equal(t, 4, got[0].Rows())
+		require.Len(t, got[0].Meta.Notices, 1)
+	})
+}
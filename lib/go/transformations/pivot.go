@@ -0,0 +1,195 @@
+package transformations
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type PivotOptions struct {
+	// By is the list of the row key fields. Output has one row per unique combination of the key field values
+	By []string `json:"by,omitempty"`
+	// Column is the field whose values become the output field names
+	Column string `json:"column"`
+	// Value is the field whose values become the values of the output fields
+	Value string `json:"value"`
+}
+
+// Pivot converts each frame from long to wide format. Output has the key fields followed by one nullable field per unique value of the column field.
+// When the same key and column appears more than once, the last value is used.
+// Null column values become the field named `null`. Frames with both null and "null" column values are not pivoted
+func Pivot(input []*data.Frame, options PivotOptions) ([]*data.Frame, error) {
+	output := []*data.Frame{}
+	for _, frame := range input {
+		pivotFrame, err := pivot(frame, options)
+		if err != nil {
+			return output, err
+		}
+		output = append(output, pivotFrame)
+	}
+	return output, nil
+}
+
+// nullColumn is the pivot column key of the null column values
+const nullColumn = "\x00null"
+
+func pivot(frame *data.Frame, options PivotOptions) (*data.Frame, error) {
+	if frame == nil {
+		return frame, nil
+	}
+	keyFields := []*data.Field{}
+	for _, name := range options.By {
+		idx := getFieldIndex(frame, name)
+		if idx < 0 {
+			return frame, fmt.Errorf("%w. %q", ErrPivotFieldNotFound, name)
+		}
+		keyFields = append(keyFields, frame.Fields[idx])
+	}
+	columnIdx, valueIdx := getFieldIndex(frame, options.Column), getFieldIndex(frame, options.Value)
+	if columnIdx < 0 {
+		return frame, fmt.Errorf("%w. %q", ErrPivotFieldNotFound, options.Column)
+	}
+	if valueIdx < 0 {
+		return frame, fmt.Errorf("%w. %q", ErrPivotFieldNotFound, options.Value)
+	}
+	columnField, valueField := frame.Fields[columnIdx], frame.Fields[valueIdx]
+	rowLen, err := frame.RowLen()
+	if err != nil {
+		return frame, err
+	}
+	rowIndex, columnIndex := map[string]int{}, map[string]int{}
+	firstRows, columns := []int{}, []string{}
+	// cells holds the source row of each output row and column
+	cells := []map[int]int{}
+	for row := 0; row < rowLen; row++ {
+		key := groupKey(keyFields, row)
+		outRow, ok := rowIndex[key]
+		if !ok {
+			outRow = len(firstRows)
+			rowIndex[key] = outRow
+			firstRows = append(firstRows, row)
+			cells = append(cells, map[int]int{})
+		}
+		// null column values are indexed separately so that they are not mixed with "null" string values
+		column := nullColumn
+		if value, ok := columnField.ConcreteAt(row); ok {
+			column = toString(value)
+		}
+		outColumn, ok := columnIndex[column]
+		if !ok {
+			outColumn = len(columns)
+			columnIndex[column] = outColumn
+			columns = append(columns, column)
+		}
+		cells[outRow][outColumn] = row
+	}
+	_, hasNull := columnIndex[nullColumn]
+	if _, ok := columnIndex["null"]; ok && hasNull {
+		return frame, fmt.Errorf("%w. %q", ErrPivotColumnNameCollision, columnField.Name)
+	}
+	out := copyRows(&data.Frame{Name: frame.Name, RefID: frame.RefID, Meta: frame.Meta, Fields: keyFields}, firstRows)
+	for outColumn, column := range columns {
+		field := newFieldLike(valueField, valueField.Type().NullableType(), len(firstRows))
+		field.Name = column
+		if column == nullColumn {
+			field.Name = "null"
+		}
+		for outRow := range firstRows {
+			if row, ok := cells[outRow][outColumn]; ok {
+				setConcrete(field, outRow, valueField, row)
+			}
+		}
+		out.Fields = append(out.Fields, field)
+	}
+	return out, nil
+}
+
+type UnpivotOptions struct {
+	// By is the list of the id fields kept as it is
+	By []string `json:"by,omitempty"`
+	// Fields is the list of fields converted to rows. Defaults to all the fields other than the id fields
+	Fields []string `json:"fields,omitempty"`
+	// VariableName is the name of the output field holding the field names. Defaults to `variable`
+	VariableName string `json:"variableName,omitempty"`
+	// ValueName is the name of the output field holding the field values. Defaults to `value`
+	ValueName string `json:"valueName,omitempty"`
+}
+
+// Unpivot (melt) converts each frame from wide to long format. Each row is converted into one row per unpivot field
+// with the id fields, the name of the field and the value of the field. Unpivot fields must be of same type
+func Unpivot(input []*data.Frame, options UnpivotOptions) ([]*data.Frame, error) {
+	output := []*data.Frame{}
+	for _, frame := range input {
+		unpivotFrame, err := unpivot(frame, options)
+		if err != nil {
+			return output, err
+		}
+		output = append(output, unpivotFrame)
+	}
+	return output, nil
+}
+
+func unpivot(frame *data.Frame, options UnpivotOptions) (*data.Frame, error) {
+	if frame == nil {
+		return frame, nil
+	}
+	variableName, valueName := options.VariableName, options.ValueName
+	if variableName == "" {
+		variableName = "variable"
+	}
+	if valueName == "" {
+		valueName = "value"
+	}
+	idFields := []int{}
+	for _, name := range options.By {
+		idx := getFieldIndex(frame, name)
+		if idx < 0 {
+			return frame, fmt.Errorf("%w. %q", ErrUnpivotFieldNotFound, name)
+		}
+		idFields = append(idFields, idx)
+	}
+	valueFields := []*data.Field{}
+	if len(options.Fields) == 0 {
+		valueFields = nonKeyFields(frame, idFields)
+	}
+	for _, name := range options.Fields {
+		idx := getFieldIndex(frame, name)
+		if idx < 0 {
+			return frame, fmt.Errorf("%w. %q", ErrUnpivotFieldNotFound, name)
+		}
+		valueFields = append(valueFields, frame.Fields[idx])
+	}
+	valueType := data.FieldTypeNullableString
+	for idx, field := range valueFields {
+		if idx > 0 && field.Type().NullableType() != valueType {
+			return frame, fmt.Errorf("%w. %q", ErrUnpivotDifferentFieldTypes, field.Name)
+		}
+		valueType = field.Type().NullableType()
+	}
+	rowLen, err := frame.RowLen()
+	if err != nil {
+		return frame, err
+	}
+	rows := []int{}
+	for row := 0; row < rowLen; row++ {
+		for range valueFields {
+			rows = append(rows, row)
+		}
+	}
+	idFrame := &data.Frame{Name: frame.Name, RefID: frame.RefID, Meta: copyMeta(frame.Meta), Fields: []*data.Field{}}
+	for _, idx := range idFields {
+		idFrame.Fields = append(idFrame.Fields, frame.Fields[idx])
+	}
+	out := copyRows(idFrame, rows)
+	variableField := data.NewFieldFromFieldType(data.FieldTypeString, len(rows))
+	variableField.Name = variableName
+	valueField := data.NewFieldFromFieldType(valueType, len(rows))
+	valueField.Name = valueName
+	for i, row := range rows {
+		field := valueFields[i%len(valueFields)]
+		variableField.Set(i, field.Name)
+		setConcrete(valueField, i, field, row)
+	}
+	out.Fields = append(out.Fields, variableField, valueField)
+	return out, nil
+}
@@ -0,0 +1,85 @@
+package transformations_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/transformations"
+	"github.com/grafana/infinity-libs/lib/go/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPivot(t *testing.T) {
+	long := data.NewFrame("metrics",
+		data.NewField("host", nil, []string{"a", "a", "b", "b", "a"}),
+		data.NewField("metric", nil, []string{"cpu", "mem", "cpu", "disk", "cpu"}),
+		data.NewField("value", nil, []float64{1, 2, 3, 4, 5}),
+	)
+	t.Run("pivot", func(t *testing.T) {
+		got, err := transformations.Pivot([]*data.Frame{long}, transformations.PivotOptions{By: []string{"host"}, Column: "metric", Value: "value"})
+		require.Nil(t, err)
+		require.Equal(t, []*data.Frame{data.NewFrame("metrics",
+			data.NewField("host", nil, []string{"a", "b"}),
+			data.NewField("cpu", nil, []*float64{utils.P(5.0), utils.P(3.0)}),
+			data.NewField("mem", nil, []*float64{utils.P(2.0), nil}),
+			data.NewField("disk", nil, []*float64{nil, utils.P(4.0)}),
+		)}, got)
+	})
+	t.Run("pivot with unknown field", func(t *testing.T) {
+		_, err := transformations.Pivot([]*data.Frame{long}, transformations.PivotOptions{By: []string{"host"}, Column: "foo", Value: "value"})
+		require.ErrorIs(t, err, transformations.ErrPivotFieldNotFound)
+	})
+	t.Run("pivot with null column values", func(t *testing.T) {
+		frame := data.NewFrame("metrics",
+			data.NewField("host", nil, []string{"a", "b"}),
+			data.NewField("metric", nil, []*string{utils.P("cpu"), nil}),
+			data.NewField("value", nil, []float64{1, 2}),
+		)
+		got, err := transformations.Pivot([]*data.Frame{frame}, transformations.PivotOptions{By: []string{"host"}, Column: "metric", Value: "value"})
+		require.Nil(t, err)
+		require.Equal(t, []*data.Frame{data.NewFrame("metrics",
+			data.NewField("host", nil, []string{"a", "b"}),
+			data.NewField("cpu", nil, []*float64{utils.P(1.0), nil}),
+			data.NewField("null", nil, []*float64{nil, utils.P(2.0)}),
+		)}, got)
+	})
+	t.Run("pivot with null and \"null\" column values", func(t *testing.T) {
+		frame := data.NewFrame("metrics",
+			data.NewField("host", nil, []string{"a", "b"}),
+			data.NewField("metric", nil, []*string{utils.P("null"), nil}),
+			data.NewField("value", nil, []float64{1, 2}),
+		)
+		_, err := transformations.Pivot([]*data.Frame{frame}, transformations.PivotOptions{By: []string{"host"}, Column: "metric", Value: "value"})
+		require.ErrorIs(t, err, transformations.ErrPivotColumnNameCollision)
+	})
+	wide := data.NewFrame("hosts",
+		data.NewField("host", nil, []string{"a", "b"}),
+		data.NewField("cpu", nil, []float64{1, 3}),
+		data.NewField("mem", nil, []*float64{utils.P(2.0), nil}),
+		data.NewField("os", nil, []string{"linux", "windows"}),
+	)
+	t.Run("unpivot", func(t *testing.T) {
+		got, err := transformations.Unpivot([]*data.Frame{wide}, transformations.UnpivotOptions{By: []string{"host"}, Fields: []string{"cpu", "mem"}, VariableName: "metric"})
+		require.Nil(t, err)
+		require.Equal(t, []*data.Frame{data.NewFrame("hosts",
+			data.NewField("host", nil, []string{"a", "a", "b", "b"}),
+			data.NewField("metric", nil, []string{"cpu", "mem", "cpu", "mem"}),
+			data.NewField("value", nil, []*float64{utils.P(1.0), utils.P(2.0), utils.P(3.0), nil}),
+		)}, got)
+	})
+	t.Run("unpivot all the fields with different types", func(t *testing.T) {
+		_, err := transformations.Unpivot([]*data.Frame{wide}, transformations.UnpivotOptions{By: []string{"host"}})
+		require.ErrorIs(t, err, transformations.ErrUnpivotDifferentFieldTypes)
+	})
+	t.Run("pivot and unpivot using pipeline", func(t *testing.T) {
+		pipeline, err := transformations.ParsePipeline([]byte(`[{"type":"pivot","options":{"by":["host"],"column":"metric","value":"value"}},{"type":"melt","options":{"by":["host"],"variableName":"metric"}}]`))
+		require.Nil(t, err)
+		got, err := pipeline.Apply([]*data.Frame{long})
+		require.Nil(t, err)
+		require.Equal(t, []*data.Frame{data.NewFrame("metrics",
+			data.NewField("host", nil, []string{"a", "a", "a", "b", "b", "b"}),
+			data.NewField("metric", nil, []string{"cpu", "mem", "disk", "cpu", "mem", "disk"}),
+			data.NewField("value", nil, []*float64{utils.P(5.0), utils.P(2.0), nil, utils.P(3.0), nil, utils.P(4.0)}),
+		)}, got)
+	})
+}
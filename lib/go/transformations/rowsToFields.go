@@ -0,0 +1,62 @@
+package transformations
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type RowsToFieldsOptions struct {
+	// NameField is the field whose values become the output field names. Defaults to the first string field
+	NameField string `json:"nameField,omitempty"`
+	// ValueField is the field whose values become the output field values. Defaults to the first numeric field
+	ValueField string `json:"valueField,omitempty"`
+}
+
+// RowsToFields converts each row of the frame into a field. Output frame has a single row with one field per input row
+// Ref: https://github.com/grafana/grafana/blob/v9.5.2/packages/grafana-data/src/transformations/transformers/rowsToFields/rowsToFields.ts
+func RowsToFields(input []*data.Frame, options RowsToFieldsOptions) ([]*data.Frame, error) {
+	output := []*data.Frame{}
+	for _, frame := range input {
+		fieldsFrame, err := rowsToFields(frame, options)
+		if err != nil {
+			return output, err
+		}
+		output = append(output, fieldsFrame)
+	}
+	return output, nil
+}
+
+func rowsToFields(frame *data.Frame, options RowsToFieldsOptions) (*data.Frame, error) {
+	if frame == nil {
+		return frame, nil
+	}
+	nameIdx := slices.IndexFunc(frame.Fields, func(f *data.Field) bool { return f.Type().NonNullableType() == data.FieldTypeString })
+	if options.NameField != "" {
+		nameIdx = getFieldIndex(frame, options.NameField)
+	}
+	valueIdx := slices.IndexFunc(frame.Fields, func(f *data.Field) bool { return f.Type().Numeric() })
+	if options.ValueField != "" {
+		valueIdx = getFieldIndex(frame, options.ValueField)
+	}
+	if nameIdx < 0 {
+		return frame, fmt.Errorf("%w. name field %q", ErrRowsToFieldsFieldNotFound, options.NameField)
+	}
+	if valueIdx < 0 {
+		return frame, fmt.Errorf("%w. value field %q", ErrRowsToFieldsFieldNotFound, options.ValueField)
+	}
+	nameField, valueField := frame.Fields[nameIdx], frame.Fields[valueIdx]
+	out := &data.Frame{Name: frame.Name, RefID: frame.RefID, Meta: copyMeta(frame.Meta), Fields: []*data.Field{}}
+	for row := 0; row < nameField.Len(); row++ {
+		name, ok := nameField.ConcreteAt(row)
+		if !ok {
+			continue
+		}
+		field := newFieldLike(valueField, valueField.Type(), 1)
+		field.Name = toString(name)
+		field.Set(0, valueField.CopyAt(row))
+		out.Fields = append(out.Fields, field)
+	}
+	return out, nil
+}
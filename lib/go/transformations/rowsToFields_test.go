@@ -0,0 +1,51 @@
+package transformations_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/transformations"
+	"github.com/grafana/infinity-libs/lib/go/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowsToFields(t *testing.T) {
+	frame := data.NewFrame("stats",
+		data.NewField("id", nil, []int64{1, 2, 3}),
+		data.NewField("name", nil, []*string{utils.P("min"), nil, utils.P("max")}),
+		data.NewField("value", nil, []float64{1, 2, 3}),
+		data.NewField("label", nil, []string{"x", "y", "z"}),
+	)
+	tests := []struct {
+		name    string
+		options transformations.RowsToFieldsOptions
+		want    *data.Frame
+		wantErr error
+	}{
+		{
+			name: "default name and value fields",
+			want: data.NewFrame("stats", data.NewField("min", nil, []int64{1}), data.NewField("max", nil, []int64{3})),
+		},
+		{
+			name:    "name and value fields",
+			options: transformations.RowsToFieldsOptions{NameField: "label", ValueField: "value"},
+			want:    data.NewFrame("stats", data.NewField("x", nil, []float64{1}), data.NewField("y", nil, []float64{2}), data.NewField("z", nil, []float64{3})),
+		},
+		{
+			name:    "unknown field",
+			options: transformations.RowsToFieldsOptions{ValueField: "foo"},
+			wantErr: transformations.ErrRowsToFieldsFieldNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformations.RowsToFields([]*data.Frame{frame}, tt.options)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, []*data.Frame{tt.want}, got)
+		})
+	}
+}
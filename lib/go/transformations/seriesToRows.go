@@ -0,0 +1,81 @@
+package transformations
+
+import (
+	"slices"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type SeriesToRowsOptions struct {
+}
+
+// SeriesToRows converts the time series frames into a single frame with Time, Metric and Value fields sorted by time in descending order.
+// Each numeric field of the frames becomes the rows of the metric. Metric is the frame name when the frame has a single numeric field,
+// otherwise the field name along with the labels. Frames without time field are ignored
+// Ref: https://github.com/grafana/grafana/blob/v9.5.2/packages/grafana-data/src/transformations/transformers/seriesToRows.ts
+func SeriesToRows(input []*data.Frame, options SeriesToRowsOptions) ([]*data.Frame, error) {
+	type seriesRow struct {
+		time   any
+		metric string
+		value  *float64
+	}
+	rows := []seriesRow{}
+	var first *data.Frame
+	for _, frame := range input {
+		if frame == nil {
+			continue
+		}
+		if first == nil {
+			first = frame
+		}
+		timeIdx := slices.IndexFunc(frame.Fields, func(f *data.Field) bool { return f.Type().Time() })
+		if timeIdx < 0 {
+			continue
+		}
+		valueFields := []*data.Field{}
+		for _, field := range frame.Fields {
+			if field.Type().Numeric() {
+				valueFields = append(valueFields, field)
+			}
+		}
+		for _, field := range valueFields {
+			metric := field.Name
+			if len(field.Labels) > 0 {
+				metric += " {" + field.Labels.String() + "}"
+			}
+			if len(valueFields) == 1 && frame.Name != "" {
+				metric = frame.Name
+			}
+			for row := 0; row < field.Len(); row++ {
+				t, ok := frame.Fields[timeIdx].ConcreteAt(row)
+				if !ok {
+					continue
+				}
+				r := seriesRow{time: t, metric: metric}
+				if value, ok := field.ConcreteAt(row); ok {
+					if v, ok := toFloat64(value); ok {
+						r.value = &v
+					}
+				}
+				rows = append(rows, r)
+			}
+		}
+	}
+	if first == nil {
+		return input, nil
+	}
+	slices.SortStableFunc(rows, func(a, b seriesRow) int { return compareValues(b.time, a.time, false) })
+	out := data.NewFrame(first.Name,
+		data.NewFieldFromFieldType(data.FieldTypeTime, len(rows)),
+		data.NewFieldFromFieldType(data.FieldTypeString, len(rows)),
+		data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, len(rows)),
+	)
+	out.RefID, out.Meta = first.RefID, copyMeta(first.Meta)
+	out.Fields[0].Name, out.Fields[1].Name, out.Fields[2].Name = "Time", "Metric", "Value"
+	for i, r := range rows {
+		out.Fields[0].Set(i, r.time)
+		out.Fields[1].Set(i, r.metric)
+		out.Fields[2].Set(i, r.value)
+	}
+	return []*data.Frame{out}, nil
+}
@@ -0,0 +1,28 @@
+package transformations_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/transformations"
+	"github.com/grafana/infinity-libs/lib/go/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesToRows(t *testing.T) {
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Minute)
+	t3 := t2.Add(time.Minute)
+	got, err := transformations.SeriesToRows([]*data.Frame{
+		data.NewFrame("cpu", data.NewField("time", nil, []time.Time{t1, t3}), data.NewField("value", nil, []int64{1, 3})),
+		data.NewFrame("", data.NewField("time", nil, []*time.Time{&t2}), data.NewField("mem", data.Labels{"host": "a"}, []*float64{nil}), data.NewField("disk", nil, []float64{2})),
+		data.NewFrame("info", data.NewField("name", nil, []string{"no time field"}), data.NewField("value", nil, []float64{10})),
+	}, transformations.SeriesToRowsOptions{})
+	require.Nil(t, err)
+	require.Equal(t, []*data.Frame{data.NewFrame("cpu",
+		data.NewField("Time", nil, []time.Time{t3, t2, t2, t1}),
+		data.NewField("Metric", nil, []string{"cpu", "mem {host=a}", "disk", "cpu"}),
+		data.NewField("Value", nil, []*float64{utils.P(3.0), nil, utils.P(2.0), utils.P(1.0)}),
+	)}, got)
+}
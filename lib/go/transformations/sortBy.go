@@ -0,0 +1,85 @@
+package transformations
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type SortByOptions struct {
+	Sort []SortByField `json:"sort,omitempty"`
+}
+
+// SortByField is the sort key. Rows are sorted by the first key and the ties are sorted by the next key
+type SortByField struct {
+	// Field is the name of the field to sort by
+	Field string `json:"field"`
+	// Desc sorts in descending order. Default is ascending
+	Desc bool `json:"desc,omitempty"`
+	// NullsFirst places the null values before the non null values. Default is nulls last regardless of the order
+	NullsFirst bool `json:"nullsFirst,omitempty"`
+	// Natural sorts the strings with the numbers in numeric order. Example: item2 before item10
+	Natural bool `json:"natural,omitempty"`
+}
+
+// SortBy sorts the rows of each frame by the sort keys. Sort is stable and the input frames are not modified
+func SortBy(input []*data.Frame, options SortByOptions) ([]*data.Frame, error) {
+	output := []*data.Frame{}
+	for _, frame := range input {
+		sortedFrame, err := SortFrame(frame, options.Sort)
+		if err != nil {
+			return output, err
+		}
+		output = append(output, sortedFrame)
+	}
+	return output, nil
+}
+
+// SortFrame returns a copy of the frame with the rows sorted by the sort keys
+func SortFrame(frame *data.Frame, sortBy []SortByField) (*data.Frame, error) {
+	if frame == nil || len(sortBy) == 0 {
+		return frame, nil
+	}
+	fields := make([]*data.Field, len(sortBy))
+	for i, s := range sortBy {
+		idx := getFieldIndex(frame, s.Field)
+		if idx < 0 {
+			return frame, fmt.Errorf("%w. %q", ErrSortByFieldNotFound, s.Field)
+		}
+		fields[i] = frame.Fields[idx]
+	}
+	rowLen, err := frame.RowLen()
+	if err != nil {
+		return frame, err
+	}
+	rows := rowRange(rowLen)
+	slices.SortStableFunc(rows, func(a, b int) int {
+		for i, s := range sortBy {
+			if c := compareRows(fields[i], a, b, s); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return copyRows(frame, rows), nil
+}
+
+func compareRows(field *data.Field, a, b int, s SortByField) int {
+	x, xOk := field.ConcreteAt(a)
+	y, yOk := field.ConcreteAt(b)
+	switch {
+	case !xOk && !yOk:
+		return 0
+	case !xOk || !yOk:
+		if !xOk == s.NullsFirst {
+			return -1
+		}
+		return 1
+	}
+	c := compareValues(x, y, s.Natural)
+	if s.Desc {
+		return -c
+	}
+	return c
+}
@@ -0,0 +1,78 @@
+package transformations_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/transformations"
+	"github.com/grafana/infinity-libs/lib/go/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortBy(t *testing.T) {
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	frame := data.NewFrame("servers",
+		data.NewField("name", nil, []*string{utils.P("item10"), utils.P("item2"), nil, utils.P("item1"), utils.P("item2")}),
+		data.NewField("cpu", nil, []*float64{utils.P(0.5), nil, utils.P(0.9), utils.P(0.5), utils.P(0.1)}),
+		data.NewField("count", nil, []int64{3, 1, 2, 5, 4}),
+		data.NewField("up", nil, []*bool{utils.P(true), utils.P(false), nil, utils.P(true), utils.P(false)}),
+		data.NewField("updated", nil, []*time.Time{&t2, &t1, nil, &t1, &t2}),
+	).SetMeta(&data.FrameMeta{PreferredVisualizationPluginID: "table"})
+	tests := []struct {
+		name      string
+		sort      []transformations.SortByField
+		wantCount []int64
+		wantErr   error
+	}{
+		{name: "no sort keys", wantCount: []int64{3, 1, 2, 5, 4}},
+		{name: "int ascending", sort: []transformations.SortByField{{Field: "count"}}, wantCount: []int64{1, 2, 3, 4, 5}},
+		{name: "int descending", sort: []transformations.SortByField{{Field: "count", Desc: true}}, wantCount: []int64{5, 4, 3, 2, 1}},
+		{name: "nullable float with nulls last", sort: []transformations.SortByField{{Field: "cpu"}}, wantCount: []int64{4, 3, 5, 2, 1}},
+		{name: "nullable float descending with nulls last", sort: []transformations.SortByField{{Field: "cpu", Desc: true}}, wantCount: []int64{2, 3, 5, 4, 1}},
+		{name: "nullable float with nulls first", sort: []transformations.SortByField{{Field: "cpu", NullsFirst: true}}, wantCount: []int64{1, 4, 3, 5, 2}},
+		{name: "string", sort: []transformations.SortByField{{Field: "name"}}, wantCount: []int64{5, 3, 1, 4, 2}},
+		{name: "natural string", sort: []transformations.SortByField{{Field: "name", Natural: true}}, wantCount: []int64{5, 1, 4, 3, 2}},
+		{name: "multiple keys", sort: []transformations.SortByField{{Field: "up", Desc: true}, {Field: "count", Desc: true}}, wantCount: []int64{5, 3, 4, 1, 2}},
+		{name: "time and natural string", sort: []transformations.SortByField{{Field: "updated"}, {Field: "name", Natural: true, Desc: true}}, wantCount: []int64{1, 5, 3, 4, 2}},
+		{name: "unknown field", sort: []transformations.SortByField{{Field: "foo"}}, wantErr: transformations.ErrSortByFieldNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformations.SortBy([]*data.Frame{frame}, transformations.SortByOptions{Sort: tt.sort})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Len(t, got, 1)
+			require.Equal(t, frame.Meta, got[0].Meta)
+			counts := []int64{}
+			for i := 0; i < got[0].Rows(); i++ {
+				counts = append(counts, got[0].Fields[2].At(i).(int64))
+			}
+			require.Equal(t, tt.wantCount, counts)
+		})
+	}
+	t.Run("input frame is not modified", func(t *testing.T) {
+		_, err := transformations.SortBy([]*data.Frame{frame}, transformations.SortByOptions{Sort: []transformations.SortByField{{Field: "count"}}})
+		require.Nil(t, err)
+		require.Equal(t, []int64{3, 1, 2, 5, 4}, []int64{frame.Fields[2].At(0).(int64), frame.Fields[2].At(1).(int64), frame.Fields[2].At(2).(int64), frame.Fields[2].At(3).(int64), frame.Fields[2].At(4).(int64)})
+	})
+	t.Run("notices of the output frame are not added to the input frame", func(t *testing.T) {
+		input := data.NewFrame("a", data.NewField("count", nil, []int64{2, 1})).SetMeta(&data.FrameMeta{Notices: []data.Notice{{Text: "input"}}})
+		got, err := transformations.SortBy([]*data.Frame{input}, transformations.SortByOptions{Sort: []transformations.SortByField{{Field: "count"}}})
+		require.Nil(t, err)
+		got[0].AppendNotices(data.Notice{Text: "output"})
+		require.Equal(t, []data.Notice{{Text: "input"}}, input.Meta.Notices)
+		require.Equal(t, []data.Notice{{Text: "input"}, {Text: "output"}}, got[0].Meta.Notices)
+	})
+	t.Run("sort then limit", func(t *testing.T) {
+		pipeline, err := transformations.ParsePipeline([]byte(`[{"type":"sortBy","options":{"sort":[{"field":"cpu","desc":true}]}},{"type":"limit","options":{"limitField":2}}]`))
+		require.Nil(t, err)
+		got, err := pipeline.Apply([]*data.Frame{frame})
+		require.Nil(t, err)
+		require.Equal(t, []*float64{utils.P(0.9), utils.P(0.5)}, []*float64{got[0].Fields[1].At(0).(*float64), got[0].Fields[1].At(1).(*float64)})
+	})
+}
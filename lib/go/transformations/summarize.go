@@ -9,6 +9,25 @@ import (
 	"github.com/grafana/infinity-libs/lib/go/framesql"
 )
 
+type SummarizeOptions struct {
+	Expression string `json:"expression,omitempty"`
+	By         string `json:"by,omitempty"`
+	Alias      string `json:"alias,omitempty"`
+}
+
+// Summarize replaces each frame with its summary frame
+func Summarize(input []*data.Frame, options SummarizeOptions) ([]*data.Frame, error) {
+	output := []*data.Frame{}
+	for _, frame := range input {
+		summaryFrame, err := GetSummaryFrame(frame, options.Expression, options.By, options.Alias)
+		if err != nil {
+			return output, err
+		}
+		output = append(output, summaryFrame)
+	}
+	return output, nil
+}
+
 func GetSummaryFrame(frame *data.Frame, expression string, by string, alias string) (*data.Frame, error) {
 	if frame == nil {
 		return frame, nil
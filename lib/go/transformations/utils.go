@@ -1,6 +1,15 @@
 package transformations
 
-import "github.com/grafana/grafana-plugin-sdk-go/data"
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/framesql"
+)
 
 // FieldExists checks if a field exist in a frame
 // only field type and field name for uniqueness
@@ -12,3 +21,196 @@ func FieldExists(frame *data.Frame, field *data.Field) bool {
 	}
 	return false
 }
+
+// getFieldIndex returns the index of the field matching the name or the slugified name. Returns -1 when the field not found
+func getFieldIndex(frame *data.Frame, name string) int {
+	for idx, field := range frame.Fields {
+		if field.Name == name || framesql.SlugifyFieldName(field.Name) == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// copyRows returns a new frame with the given rows of the frame in the given order. Input frame is not modified
+func copyRows(frame *data.Frame, rows []int) *data.Frame {
+	out := &data.Frame{Name: frame.Name, RefID: frame.RefID, Meta: copyMeta(frame.Meta), Fields: make([]*data.Field, 0, len(frame.Fields))}
+	for _, field := range frame.Fields {
+		outField := newFieldLike(field, field.Type(), len(rows))
+		for i, row := range rows {
+			outField.Set(i, field.CopyAt(row))
+		}
+		out.Fields = append(out.Fields, outField)
+	}
+	return out
+}
+
+// copyMeta returns a copy of the frame meta so that the notices appended to the output frame are not added to the input frame
+func copyMeta(meta *data.FrameMeta) *data.FrameMeta {
+	if meta == nil {
+		return nil
+	}
+	out := *meta
+	out.Notices = slices.Clone(meta.Notices)
+	return &out
+}
+
+// newFieldLike returns an empty field of the given type and length with the name, labels and config of the field
+func newFieldLike(field *data.Field, fieldType data.FieldType, length int) *data.Field {
+	out := data.NewFieldFromFieldType(fieldType, length)
+	out.Name = field.Name
+	if field.Labels != nil {
+		out.Labels = field.Labels.Copy()
+	}
+	out.Config = field.Config
+	return out
+}
+
+// setConcrete copies the value of the row of the source field into the field. Null values are left as nil
+func setConcrete(field *data.Field, idx int, source *data.Field, row int) {
+	if value, ok := source.ConcreteAt(row); ok {
+		field.SetConcrete(idx, value)
+	}
+}
+
+// rowRange returns the row indexes from 0 to length-1
+func rowRange(length int) []int {
+	rows := make([]int, length)
+	for i := range rows {
+		rows[i] = i
+	}
+	return rows
+}
+
+// compareValues compares the concrete (non pointer) values of the field. Values of different types are compared as strings
+func compareValues(a, b any, natural bool) int {
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			if natural {
+				return compareNatural(x, y)
+			}
+			return strings.Compare(x, y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			default:
+				return 1
+			}
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	case json.RawMessage:
+		if y, ok := b.(json.RawMessage); ok {
+			return strings.Compare(string(x), string(y))
+		}
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64, data.EnumItemIndex:
+		if x, ok := toInt64(a); ok {
+			if y, ok := toInt64(b); ok {
+				return cmp.Compare(x, y)
+			}
+		}
+	}
+	if x, ok := toFloat64(a); ok {
+		if y, ok := toFloat64(b); ok {
+			return cmp.Compare(x, y)
+		}
+	}
+	return strings.Compare(toString(a), toString(b))
+}
+
+func toInt64(v any) (int64, bool) {
+	switch x := v.(type) {
+	case int8:
+		return int64(x), true
+	case int16:
+		return int64(x), true
+	case int32:
+		return int64(x), true
+	case int64:
+		return x, true
+	case uint8:
+		return int64(x), true
+	case uint16:
+		return int64(x), true
+	case uint32:
+		return int64(x), true
+	case uint64:
+		if x > 1<<63-1 {
+			return 0, false
+		}
+		return int64(x), true
+	case data.EnumItemIndex:
+		return int64(x), true
+	}
+	return 0, false
+}
+
+func toFloat64(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float32:
+		return float64(x), true
+	case float64:
+		return x, true
+	case uint64:
+		return float64(x), true
+	}
+	if i, ok := toInt64(v); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+func toString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case json.RawMessage:
+		return string(x)
+	case time.Time:
+		return x.UTC().Format(time.RFC3339Nano)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// compareNatural compares the strings treating the runs of digits as numbers. Example: item2 < item10
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		aDigits, bDigits := leadingDigits(a), leadingDigits(b)
+		if aDigits == "" || bDigits == "" {
+			if c := cmp.Compare(a[0], b[0]); c != 0 {
+				return c
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		trimmedA, trimmedB := strings.TrimLeft(aDigits, "0"), strings.TrimLeft(bDigits, "0")
+		if c := cmp.Compare(len(trimmedA), len(trimmedB)); c != 0 {
+			return c
+		}
+		if c := strings.Compare(trimmedA, trimmedB); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(len(aDigits), len(bDigits)); c != 0 {
+			return c
+		}
+		a, b = a[len(aDigits):], b[len(bDigits):]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
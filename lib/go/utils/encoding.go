@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+type Encoding string
+
+const (
+	EncodingUTF8        Encoding = "utf-8"
+	EncodingUTF16LE     Encoding = "utf-16le"
+	EncodingUTF16BE     Encoding = "utf-16be"
+	EncodingLatin1      Encoding = "latin1"
+	EncodingWindows1252 Encoding = "windows-1252"
+	EncodingAuto        Encoding = "auto"
+)
+
+var ErrUnsupportedEncoding = errors.New("unsupported character encoding")
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// DecodeString transcodes the input from the given encoding into UTF-8 and removes the byte order mark, if any.
+// Empty encoding is treated as UTF-8.
+// When the encoding is `auto`, the encoding is detected from the byte order mark.
+// Input without byte order mark is treated as UTF-8.
+func DecodeString(input string, enc Encoding) (string, error) {
+	in := []byte(input)
+	enc = Encoding(strings.ToLower(string(enc)))
+	if enc == EncodingAuto {
+		enc = DetectEncoding(in)
+	}
+	var decoder *encoding.Decoder
+	switch enc {
+	case "", EncodingUTF8, "utf8":
+		return string(bytes.TrimPrefix(in, bomUTF8)), nil
+	case EncodingUTF16LE:
+		decoder = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	case EncodingUTF16BE:
+		decoder = unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	case EncodingLatin1, "iso-8859-1":
+		decoder = charmap.ISO8859_1.NewDecoder()
+	case EncodingWindows1252, "cp1252":
+		decoder = charmap.Windows1252.NewDecoder()
+	default:
+		return input, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, enc)
+	}
+	out, err := decoder.Bytes(in)
+	if err != nil {
+		return input, fmt.Errorf("error decoding %s content. %w", enc, err)
+	}
+	return string(bytes.TrimPrefix(out, bomUTF8)), nil
+}
+
+// DetectEncoding detects the encoding of the input using the byte order mark. Defaults to UTF-8
+func DetectEncoding(input []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(input, bomUTF8):
+		return EncodingUTF8
+	case bytes.HasPrefix(input, bomUTF16LE):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(input, bomUTF16BE):
+		return EncodingUTF16BE
+	default:
+		return EncodingUTF8
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/grafana/infinity-libs/lib/go/utils
 
-go 1.24.6
+go 1.25.0
+
+require golang.org/x/text v0.37.0
@@ -0,0 +1,220 @@
+package xmlframer
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	defaultAttributePrefix = "-"
+	defaultTextNodeKey     = "#content"
+	xmlnsPrefix            = "xmlns"
+)
+
+type NamespaceMode string
+
+const (
+	NamespaceModeStrip NamespaceMode = "strip"
+	NamespaceModeKeep  NamespaceMode = "keep"
+	NamespaceModeAlias NamespaceMode = "alias"
+)
+
+// node represents a xml element with its attributes and child elements
+type node struct {
+	keys     []string
+	children map[string][]*node
+	data     string
+}
+
+func (n *node) addChild(key string, child *node) {
+	if n.children == nil {
+		n.children = map[string][]*node{}
+	}
+	if _, ok := n.children[key]; !ok {
+		n.keys = append(n.keys, key)
+	}
+	n.children[key] = append(n.children[key], child)
+}
+
+func (n *node) isComplex() bool {
+	return len(n.children) > 0
+}
+
+type converter struct {
+	attributePrefix  string
+	textNodeKey      string
+	namespaceMode    NamespaceMode
+	namespaceAliases map[string]string
+	// namespaces holds the namespace uri to prefix mapping for each level of the xml tree
+	namespaces []map[string]string
+}
+
+func newConverter(options FramerOptions) *converter {
+	c := &converter{
+		attributePrefix:  options.AttributePrefix,
+		textNodeKey:      options.TextNodeKey,
+		namespaceMode:    options.NamespaceMode,
+		namespaceAliases: options.NamespaceAliases,
+	}
+	if c.attributePrefix == "" {
+		c.attributePrefix = defaultAttributePrefix
+	}
+	if c.textNodeKey == "" {
+		c.textNodeKey = defaultTextNodeKey
+	}
+	if c.namespaceMode == "" {
+		c.namespaceMode = NamespaceModeStrip
+	}
+	return c
+}
+
+// convert reads the xml document and converts it into a json string
+func (c *converter) convert(r io.Reader) (string, error) {
+	out, err := json.Marshal(c.value(r))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// value reads the xml document and converts it into a json compatible value
+func (c *converter) value(r io.Reader) any {
+	return c.toValue(c.decode(r))
+}
+
+// decode builds the element tree from the xml document.
+// Similar to goxml2json, decoding stops at the first invalid token and the tokenizer error is deliberately dropped,
+// so that malformed or trailing input returns the elements decoded so far instead of failing.
+func (c *converter) decode(r io.Reader) *node {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charset.NewReaderLabel
+	root := &node{}
+	current := root
+	parents := []*node{}
+	labels := []string{}
+	for {
+		t, err := dec.Token()
+		if err == io.EOF || t == nil {
+			break
+		}
+		switch se := t.(type) {
+		case xml.StartElement:
+			c.pushNamespaces(se.Attr)
+			parents = append(parents, current)
+			labels = append(labels, c.name(se.Name))
+			current = &node{}
+			for _, a := range se.Attr {
+				current.addChild(c.attributePrefix+c.attributeName(a.Name), &node{data: a.Value})
+			}
+		case xml.CharData:
+			current.data = trimNonGraphic(string(se))
+		case xml.EndElement:
+			if len(parents) == 0 {
+				continue
+			}
+			parent := parents[len(parents)-1]
+			parent.addChild(labels[len(labels)-1], current)
+			current = parent
+			parents = parents[:len(parents)-1]
+			labels = labels[:len(labels)-1]
+			c.namespaces = c.namespaces[:len(c.namespaces)-1]
+		}
+	}
+	return root
+}
+
+func (c *converter) toValue(n *node) any {
+	if !n.isComplex() {
+		return n.data
+	}
+	out := map[string]any{}
+	if n.data != "" {
+		out[c.textNodeKey] = n.data
+	}
+	for _, key := range n.keys {
+		children := n.children[key]
+		if len(children) > 1 {
+			items := []any{}
+			for _, child := range children {
+				items = append(items, c.toValue(child))
+			}
+			out[key] = items
+			continue
+		}
+		out[key] = c.toValue(children[0])
+	}
+	return out
+}
+
+func (c *converter) pushNamespaces(attrs []xml.Attr) {
+	scope := map[string]string{}
+	for _, a := range attrs {
+		if a.Name.Space == xmlnsPrefix {
+			scope[a.Value] = a.Name.Local
+		}
+		if a.Name.Space == "" && a.Name.Local == xmlnsPrefix {
+			scope[a.Value] = ""
+		}
+	}
+	c.namespaces = append(c.namespaces, scope)
+}
+
+func (c *converter) prefix(uri string) (string, bool) {
+	for i := len(c.namespaces) - 1; i >= 0; i-- {
+		if p, ok := c.namespaces[i][uri]; ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// name returns the key of the element based on the namespace mode
+func (c *converter) name(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	switch c.namespaceMode {
+	case NamespaceModeKeep:
+		p, ok := c.prefix(name.Space)
+		if !ok {
+			// undeclared namespace prefix
+			return name.Space + ":" + name.Local
+		}
+		if p == "" {
+			// default namespace
+			return name.Local
+		}
+		return p + ":" + name.Local
+	case NamespaceModeAlias:
+		if alias, ok := c.namespaceAliases[name.Space]; ok && alias != "" {
+			return alias + ":" + name.Local
+		}
+		return name.Local
+	default:
+		return name.Local
+	}
+}
+
+// attributeName returns the key of the attribute based on the namespace mode
+func (c *converter) attributeName(name xml.Name) string {
+	if c.namespaceMode == NamespaceModeStrip {
+		return name.Local
+	}
+	if name.Space == xmlnsPrefix {
+		return xmlnsPrefix + ":" + name.Local
+	}
+	return c.name(name)
+}
+
+// trimNonGraphic returns a slice of the string s, with all leading and trailing
+// non graphic characters and spaces removed.
+func trimNonGraphic(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return !unicode.IsGraphic(r) || unicode.IsSpace(r)
+	})
+}
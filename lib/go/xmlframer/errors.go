@@ -0,0 +1,9 @@
+package xmlframer
+
+import "errors"
+
+var (
+	ErrConvertingXMLToFrame = errors.New("error converting xml to grafana data frame")
+	ErrInvalidXPathSelector = errors.New("invalid xpath selector")
+	ErrSOAPFault            = errors.New("soap fault received")
+)
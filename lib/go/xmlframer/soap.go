@@ -0,0 +1,119 @@
+package xmlframer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+)
+
+const (
+	soap11EnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12EnvelopeNamespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// SOAPFault represents the fault returned in the body of a SOAP 1.1 or SOAP 1.2 envelope.
+// For SOAP 1.2, FaultCode, FaultString and FaultActor are populated from Code/Value, Reason/Text and Role elements.
+type SOAPFault struct {
+	FaultCode   string
+	FaultString string
+	FaultActor  string
+	Detail      string
+}
+
+func (f *SOAPFault) Error() string {
+	msg := fmt.Sprintf("%s. faultcode: %s", ErrSOAPFault.Error(), f.FaultCode)
+	if f.FaultString != "" {
+		msg = fmt.Sprintf("%s, faultstring: %s", msg, f.FaultString)
+	}
+	return msg
+}
+
+func (f *SOAPFault) Unwrap() error {
+	return ErrSOAPFault
+}
+
+// unwrapSOAPEnvelope returns the content of the SOAP body when the xml document is a SOAP 1.1 or SOAP 1.2 envelope.
+// Non SOAP documents are returned as it is. When the body contains a fault, *SOAPFault error is returned.
+func unwrapSOAPEnvelope(xmlString string) (string, error) {
+	doc, err := xmlquery.Parse(strings.NewReader(xmlString))
+	if err != nil {
+		return xmlString, errors.Join(ErrConvertingXMLToFrame, err)
+	}
+	envelope := firstChildElement(doc, "Envelope", "")
+	if envelope == nil || (envelope.NamespaceURI != soap11EnvelopeNamespace && envelope.NamespaceURI != soap12EnvelopeNamespace) {
+		return xmlString, nil
+	}
+	body := firstChildElement(envelope, "Body", envelope.NamespaceURI)
+	if body == nil {
+		return "", errors.Join(ErrConvertingXMLToFrame, errors.New("soap body not found in the envelope"))
+	}
+	if fault := firstChildElement(body, "Fault", envelope.NamespaceURI); fault != nil {
+		return "", getSOAPFault(fault, envelope.NamespaceURI)
+	}
+	var sb strings.Builder
+	for child := body.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != xmlquery.ElementNode {
+			continue
+		}
+		// namespaces declared in the envelope and body are copied so the body elements can be decoded on their own
+		for _, parent := range []*xmlquery.Node{envelope, body} {
+			for _, attr := range parent.Attr {
+				if attr.Name.Space == xmlnsPrefix {
+					xmlquery.AddAttr(child, xmlnsPrefix+":"+attr.Name.Local, attr.Value)
+				}
+				if attr.Name.Space == "" && attr.Name.Local == xmlnsPrefix {
+					xmlquery.AddAttr(child, xmlnsPrefix, attr.Value)
+				}
+			}
+		}
+		sb.WriteString(child.OutputXML(true))
+	}
+	return sb.String(), nil
+}
+
+func getSOAPFault(fault *xmlquery.Node, namespace string) *SOAPFault {
+	if namespace == soap12EnvelopeNamespace {
+		return &SOAPFault{
+			FaultCode:   innerText(firstChildElement(firstChildElement(fault, "Code", namespace), "Value", namespace)),
+			FaultString: innerText(firstChildElement(firstChildElement(fault, "Reason", namespace), "Text", namespace)),
+			FaultActor:  innerText(firstChildElement(fault, "Role", namespace)),
+			Detail:      innerXML(firstChildElement(fault, "Detail", namespace)),
+		}
+	}
+	return &SOAPFault{
+		FaultCode:   innerText(firstChildElement(fault, "faultcode", "")),
+		FaultString: innerText(firstChildElement(fault, "faultstring", "")),
+		FaultActor:  innerText(firstChildElement(fault, "faultactor", "")),
+		Detail:      innerXML(firstChildElement(fault, "detail", "")),
+	}
+}
+
+// firstChildElement returns the first child element matching the local name and namespace.
+// Empty namespace matches any namespace
+func firstChildElement(n *xmlquery.Node, name string, namespace string) *xmlquery.Node {
+	if n == nil {
+		return nil
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == xmlquery.ElementNode && child.Data == name && (namespace == "" || child.NamespaceURI == namespace) {
+			return child
+		}
+	}
+	return nil
+}
+
+func innerText(n *xmlquery.Node) string {
+	if n == nil {
+		return ""
+	}
+	return strings.TrimSpace(n.InnerText())
+}
+
+func innerXML(n *xmlquery.Node) string {
+	if n == nil {
+		return ""
+	}
+	return strings.TrimSpace(n.OutputXML(false))
+}
@@ -0,0 +1,90 @@
+package xmlframer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/infinity-libs/lib/go/jsonframer"
+)
+
+// numberRegex matches the json number format. Numbers with leading zeros such as zip codes are intentionally not matched
+var numberRegex = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// convertFieldTypes converts the string fields of the frame into number or boolean fields
+// when all the non null values of the field are numbers or booleans.
+// Fields having explicit type in the column selectors are not converted.
+func convertFieldTypes(frame *data.Frame, columns []jsonframer.ColumnSelector) *data.Frame {
+	if frame == nil {
+		return frame
+	}
+	typedColumns := map[string]bool{}
+	for _, col := range columns {
+		if col.Type == "" {
+			continue
+		}
+		name := col.Alias
+		if name == "" {
+			name = col.Selector
+		}
+		typedColumns[name] = true
+	}
+	for i, field := range frame.Fields {
+		if field.Type() != data.FieldTypeNullableString || typedColumns[field.Name] {
+			continue
+		}
+		if newField := convertStringField(field); newField != nil {
+			frame.Fields[i] = newField
+		}
+	}
+	return frame
+}
+
+// convertStringField returns the number or boolean representation of the string field.
+// nil is returned when the field values are not all numbers or booleans
+func convertStringField(field *data.Field) *data.Field {
+	isNumber, isBool, hasValue := true, true, false
+	for i := 0; i < field.Len(); i++ {
+		v, ok := field.ConcreteAt(i)
+		if !ok {
+			continue
+		}
+		s := v.(string)
+		if s == "" {
+			continue
+		}
+		hasValue = true
+		isNumber = isNumber && numberRegex.MatchString(s)
+		isBool = isBool && (strings.EqualFold(s, "true") || strings.EqualFold(s, "false"))
+		if !isNumber && !isBool {
+			return nil
+		}
+	}
+	if !hasValue {
+		return nil
+	}
+	fieldType := data.FieldTypeNullableFloat64
+	if isBool {
+		fieldType = data.FieldTypeNullableBool
+	}
+	newField := data.NewFieldFromFieldType(fieldType, field.Len())
+	newField.Name = field.Name
+	newField.Labels = field.Labels
+	newField.Config = field.Config
+	for i := 0; i < field.Len(); i++ {
+		v, ok := field.ConcreteAt(i)
+		if !ok || v.(string) == "" {
+			continue
+		}
+		if isBool {
+			b := strings.EqualFold(v.(string), "true")
+			newField.Set(i, &b)
+			continue
+		}
+		if f, err := strconv.ParseFloat(v.(string), 64); err == nil {
+			newField.Set(i, &f)
+		}
+	}
+	return newField
+}
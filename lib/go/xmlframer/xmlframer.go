@@ -2,34 +2,137 @@ package xmlframer
 
 import (
 	"errors"
+	"regexp"
+	"slices"
 	"strings"
 
-	xj "github.com/basgys/goxml2json"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/infinity-libs/lib/go/jsonframer"
+	"github.com/grafana/infinity-libs/lib/go/utils"
 )
 
 type FramerOptions struct {
-	FramerType   string
-	FrameName    string
-	RootSelector string
-	Columns      []jsonframer.ColumnSelector
+	FramerType      string // `gjson` | `jsonata` | `jq` | `xpath`
+	FrameName       string
+	RootSelector    string
+	Columns         []jsonframer.ColumnSelector
+	OverrideColumns []jsonframer.ColumnSelector
+	FrameFormat     jsonframer.FrameFormat
+	// Encoding is the character encoding of the xml input. `utf-8` (default), `utf-16le`, `utf-16be`, `latin1`, `windows-1252` or `auto`
+	Encoding string
+	// AttributePrefix is the prefix added to the keys of the xml attributes. Default `-`
+	AttributePrefix string
+	// TextNodeKey is the key used for the text of the elements having attributes or child elements. Default `#content`
+	TextNodeKey string
+	// NamespaceMode controls the namespace prefix of the keys. `strip` (default), `keep` or `alias`
+	NamespaceMode NamespaceMode
+	// NamespaceAliases maps the namespace uri to the prefix used in the keys when NamespaceMode is `alias`
+	NamespaceAliases map[string]string
+	// UnwrapSOAPEnvelope detects SOAP 1.1 and SOAP 1.2 envelopes and uses the content of the body as the xml document.
+	// SOAP faults are returned as *SOAPFault error.
+	UnwrapSOAPEnvelope bool
+	// TypedConversion converts the fields into number or boolean fields when all the values are numbers or booleans.
+	// Fields with explicit column type are not converted.
+	TypedConversion bool
 }
 
+var xmlDeclarationEncodingRegex = regexp.MustCompile(`^(\s*<\?xml[^>]*?encoding\s*=\s*)["'][^"']*["']`)
+
 func ToFrame(xmlString string, options FramerOptions) (*data.Frame, error) {
-	xml := strings.NewReader(xmlString)
-	jsonStr, err := xj.Convert(xml)
+	jsonStr, framerOptions, err := toJSON(xmlString, options)
+	if err != nil {
+		return nil, err
+	}
+	frame, err := jsonframer.ToFrame(jsonStr, framerOptions)
+	if err != nil || !options.TypedConversion {
+		return frame, err
+	}
+	return convertFieldTypes(frame, slices.Concat(options.Columns, options.OverrideColumns)), nil
+}
+
+// ToFrames converts the xml string into multiple data frames similar to jsonframer.ToFrames.
+// When the root selector results in multiple sections ( array of arrays ), each section is converted into its own frame.
+func ToFrames(xmlString string, options FramerOptions) ([]*data.Frame, error) {
+	jsonStr, framerOptions, err := toJSON(xmlString, options)
+	if err != nil {
+		return nil, err
+	}
+	frames, err := jsonframer.ToFrames(jsonStr, framerOptions)
+	if err != nil {
+		return frames, err
+	}
+	for i, frame := range frames {
+		if options.TypedConversion {
+			frame = convertFieldTypes(frame, slices.Concat(options.Columns, options.OverrideColumns))
+		}
+		if options.FrameFormat == jsonframer.FrameFormatTimeSeries && frame.TimeSeriesSchema().Type == data.TimeSeriesTypeLong {
+			// xml sections are usually array of objects which are not converted into wide format by jsonframer
+			wideFrame, err := data.LongToWide(frame, nil)
+			if err != nil {
+				return frames, err
+			}
+			wideFrame.Meta = frame.Meta
+			frame = wideFrame
+		}
+		frames[i] = frame
+	}
+	return frames, nil
+}
+
+// toJSON converts the xml string into json string along with the options to be used with jsonframer
+func toJSON(xmlString string, options FramerOptions) (string, jsonframer.FramerOptions, error) {
+	xmlString, err := decodeXML(xmlString, options.Encoding)
 	if err != nil {
-		return nil, errors.Join(errors.New("error converting xml to grafana data frame"), err)
+		return "", jsonframer.FramerOptions{}, errors.Join(ErrConvertingXMLToFrame, err)
+	}
+	if options.UnwrapSOAPEnvelope {
+		xmlString, err = unwrapSOAPEnvelope(xmlString)
+		if err != nil {
+			return "", jsonframer.FramerOptions{}, err
+		}
 	}
 	framerOptions := jsonframer.FramerOptions{
-		FramerType:   jsonframer.FramerType(options.FramerType),
-		FrameName:    options.FrameName,
-		RootSelector: options.RootSelector,
-		Columns:      options.Columns,
+		FramerType:      jsonframer.FramerType(options.FramerType),
+		FrameName:       options.FrameName,
+		RootSelector:    options.RootSelector,
+		Columns:         options.Columns,
+		OverrideColumns: options.OverrideColumns,
+		FrameFormat:     options.FrameFormat,
+	}
+	if options.FramerType == FramerTypeXPath {
+		jsonStr, columns, err := applyXPathSelectors(xmlString, options)
+		if err != nil {
+			return "", framerOptions, err
+		}
+		framerOptions.FramerType = jsonframer.FramerTypeGJSON
+		framerOptions.RootSelector = ""
+		framerOptions.Columns = columns
+		return jsonStr, framerOptions, nil
+	}
+	jsonStr, err := newConverter(options).convert(strings.NewReader(xmlString))
+	if err != nil {
+		return "", framerOptions, errors.Join(ErrConvertingXMLToFrame, err)
 	}
 	if framerOptions.FramerType == "" {
 		framerOptions.FramerType = jsonframer.FramerTypeGJSON
 	}
-	return jsonframer.ToFrame(jsonStr.String(), framerOptions)
+	return jsonStr, framerOptions, nil
+}
+
+// decodeXML transcodes the xml input into UTF-8.
+// When the input is transcoded from a non UTF-8 encoding, the encoding of the xml declaration is updated to match the transcoded content.
+// Otherwise the declaration is kept as it is, so that the xml decoder transcodes the declared charset. Example: `auto` without byte order mark
+func decodeXML(xmlString string, encoding string) (string, error) {
+	out, err := utils.DecodeString(xmlString, utils.Encoding(encoding))
+	if err != nil {
+		return xmlString, err
+	}
+	enc := utils.Encoding(strings.ToLower(encoding))
+	if enc == utils.EncodingAuto {
+		enc = utils.DetectEncoding([]byte(xmlString))
+	}
+	if enc == "" || enc == utils.EncodingUTF8 || enc == "utf8" {
+		return out, nil
+	}
+	return xmlDeclarationEncodingRegex.ReplaceAllString(out, `${1}"UTF-8"`), nil
 }
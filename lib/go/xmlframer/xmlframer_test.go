@@ -0,0 +1,574 @@
+package xmlframer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/experimental"
+	"github.com/grafana/infinity-libs/lib/go/jsonframer"
+	"github.com/grafana/infinity-libs/lib/go/xmlframer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestToFrameWithDefaultOptions pins the output of the default options.
+// Golden files are generated with the goxml2json based converter used by the earlier versions
+func TestToFrameWithDefaultOptions(t *testing.T) {
+	updateTestData := false
+	tests := []struct {
+		name         string
+		xmlString    string
+		rootSelector string
+		wantErr      bool
+	}{
+		{
+			name:         "attributes",
+			xmlString:    `<users><user id="1" active="true"><name>foo</name><age>20</age></user><user id="2"><name>bar</name><age>30</age></user></users>`,
+			rootSelector: "users.user",
+		},
+		{
+			name:         "text with attributes",
+			xmlString:    `<prices><price currency="USD">10.5</price><price currency="EUR">9</price></prices>`,
+			rootSelector: "prices.price",
+		},
+		{
+			name:         "single element",
+			xmlString:    `<users><user><name>foo</name><age>20</age></user></users>`,
+			rootSelector: "users.user",
+		},
+		{
+			name:         "repeated child elements",
+			xmlString:    `<users><user><name>foo</name><tag>a</tag><tag>b</tag></user><user><name>bar</name><tag>c</tag></user></users>`,
+			rootSelector: "users.user",
+		},
+		{
+			name:         "namespaces",
+			xmlString:    `<ns:users xmlns:ns="urn:example:users" xmlns="urn:example:default"><ns:user ns:id="1"><ns:name>foo</ns:name></ns:user><ns:user ns:id="2"><ns:name>bar</ns:name></ns:user></ns:users>`,
+			rootSelector: "users.user",
+		},
+		{
+			name:         "namespace declarations",
+			xmlString:    `<users xmlns="urn:example:default" xmlns:x="urn:example:x"><user x:id="1"><name>foo</name></user><user x:id="2"><name>bar</name></user></users>`,
+			rootSelector: "users",
+		},
+		{
+			name:         "empty and whitespace elements",
+			xmlString:    "<users><user><name>foo</name><city/><country>  </country><zip></zip></user><user><name>\n\t bar \n</name><city>x</city></user></users>",
+			rootSelector: "users.user",
+		},
+		{
+			name:         "mixed content",
+			xmlString:    `<notes><note>hello <b>world</b> again</note><note>plain</note></notes>`,
+			rootSelector: "notes.note",
+		},
+		{
+			name:         "declaration comments cdata and entities",
+			xmlString:    "<?xml version=\"1.0\"?>\n<!-- users --><users><user><name><![CDATA[<foo>]]></name><city>a &amp; b</city></user><user><name>bar</name><city>&lt;c&gt;</city></user></users>",
+			rootSelector: "users.user",
+		},
+		{
+			name:         "malformed input",
+			xmlString:    `<users><user><name>foo</name></user><user><name>bar</user></users>`,
+			rootSelector: "users.user",
+			wantErr:      true,
+		},
+		{
+			name:         "trailing input",
+			xmlString:    `<users><user><name>foo</name></user></users><users><user><name>bar</name></user></users>`,
+			rootSelector: "users.user",
+			wantErr:      true,
+		},
+		{
+			name:         "trailing text",
+			xmlString:    `<users><user><name>foo</name></user></users> trailing`,
+			rootSelector: "users.user",
+		},
+		{
+			name:      "whole document",
+			xmlString: `<user id="1"><name>foo</name><age>20</age></user>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFrame, err := xmlframer.ToFrame(tt.xmlString, xmlframer.FramerOptions{RootSelector: tt.rootSelector})
+			if tt.wantErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.NotNil(t, gotFrame)
+			goldenFileName := strings.Replace(t.Name(), "TestToFrameWithDefaultOptions/", "", 1)
+			experimental.CheckGoldenJSONFrame(t, "testdata", goldenFileName, gotFrame, updateTestData)
+		})
+	}
+}
+
+func TestToFrameWithEncoding(t *testing.T) {
+	utf16be := func(s string) string {
+		out := []byte{0xFE, 0xFF}
+		for _, r := range s {
+			out = append(out, byte(r>>8), byte(r))
+		}
+		return string(out)
+	}
+	columns := []jsonframer.ColumnSelector{{Selector: "name"}, {Selector: "city"}}
+	tests := []struct {
+		name      string
+		xmlString string
+		encoding  string
+		wantCity  string
+	}{
+		{
+			name:      "utf-8 bom should be ignored",
+			xmlString: "\xEF\xBB\xBF<users><user><name>foo</name><city>Zürich</city></user></users>",
+			wantCity:  "Zürich",
+		},
+		{
+			name:      "utf-16be with bom and declaration should be transcoded",
+			xmlString: utf16be(`<?xml version="1.0" encoding="UTF-16"?><users><user><name>foo</name><city>Zürich</city></user></users>`),
+			encoding:  "auto",
+			wantCity:  "Zürich",
+		},
+		{
+			name:      "declared charset without bom should be transcoded with auto",
+			xmlString: "<?xml version='1.0' encoding='ISO-8859-1'?><users><user><name>foo</name><city>Z\xFCrich</city></user></users>",
+			encoding:  "auto",
+			wantCity:  "Zürich",
+		},
+		{
+			name:      "windows-1252 should be transcoded",
+			xmlString: "<?xml version='1.0' encoding='windows-1252'?><users><user><name>foo</name><city>Z\xFCrich</city></user></users>",
+			encoding:  "windows-1252",
+			wantCity:  "Zürich",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFrame, err := xmlframer.ToFrame(tt.xmlString, xmlframer.FramerOptions{RootSelector: "users.user", Columns: columns, Encoding: tt.encoding})
+			require.Nil(t, err)
+			require.NotNil(t, gotFrame)
+			require.Len(t, gotFrame.Fields, 2)
+			assert.Equal(t, "city", gotFrame.Fields[0].Name)
+			assert.Equal(t, tt.wantCity, *(gotFrame.Fields[0].At(0).(*string)))
+		})
+	}
+}
+
+func TestToFrameWithAttributesAndNamespaces(t *testing.T) {
+	usersXML := `<users><user id="1"><name lang="en">foo</name></user><user id="2"><name lang="fr">bar</name></user></users>`
+	soapXML := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+		<soap:Body>
+			<m:GetUsersResponse xmlns:m="urn:example:users">
+				<m:user m:id="1"><m:name>foo</m:name></m:user>
+				<m:user m:id="2"><m:name>bar</m:name></m:user>
+			</m:GetUsersResponse>
+		</soap:Body>
+	</soap:Envelope>`
+	tests := []struct {
+		name      string
+		xmlString string
+		options   xmlframer.FramerOptions
+		want      map[string][]string
+	}{
+		{
+			name:      "default attribute prefix and text node key",
+			xmlString: usersXML,
+			options: xmlframer.FramerOptions{RootSelector: "users.user", Columns: []jsonframer.ColumnSelector{
+				{Selector: "-id", Alias: "id"},
+				{Selector: "name.#content", Alias: "name"},
+				{Selector: "name.-lang", Alias: "lang"},
+			}},
+			want: map[string][]string{"id": {"1", "2"}, "name": {"foo", "bar"}, "lang": {"en", "fr"}},
+		},
+		{
+			name:      "custom attribute prefix and text node key",
+			xmlString: usersXML,
+			options: xmlframer.FramerOptions{RootSelector: "users.user", AttributePrefix: "_", TextNodeKey: "text", Columns: []jsonframer.ColumnSelector{
+				{Selector: "_id", Alias: "id"},
+				{Selector: "name.text", Alias: "name"},
+				{Selector: "name._lang", Alias: "lang"},
+			}},
+			want: map[string][]string{"id": {"1", "2"}, "name": {"foo", "bar"}, "lang": {"en", "fr"}},
+		},
+		{
+			name:      "namespaces should be stripped by default",
+			xmlString: soapXML,
+			options: xmlframer.FramerOptions{RootSelector: "Envelope.Body.GetUsersResponse.user", Columns: []jsonframer.ColumnSelector{
+				{Selector: "-id", Alias: "id"},
+				{Selector: "name"},
+			}},
+			want: map[string][]string{"id": {"1", "2"}, "name": {"foo", "bar"}},
+		},
+		{
+			name:      "namespace prefix should be kept",
+			xmlString: soapXML,
+			options: xmlframer.FramerOptions{NamespaceMode: xmlframer.NamespaceModeKeep, RootSelector: "soap:Envelope.soap:Body.m:GetUsersResponse.m:user", Columns: []jsonframer.ColumnSelector{
+				{Selector: "-m:id", Alias: "id"},
+				{Selector: "m:name", Alias: "name"},
+			}},
+			want: map[string][]string{"id": {"1", "2"}, "name": {"foo", "bar"}},
+		},
+		{
+			name:      "namespace uri should be mapped to alias",
+			xmlString: soapXML,
+			options: xmlframer.FramerOptions{
+				NamespaceMode:    xmlframer.NamespaceModeAlias,
+				NamespaceAliases: map[string]string{"urn:example:users": "u"},
+				RootSelector:     "Envelope.Body.u:GetUsersResponse.u:user",
+				Columns: []jsonframer.ColumnSelector{
+					{Selector: "-u:id", Alias: "id"},
+					{Selector: "u:name", Alias: "name"},
+				},
+			},
+			want: map[string][]string{"id": {"1", "2"}, "name": {"foo", "bar"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFrame, err := xmlframer.ToFrame(tt.xmlString, tt.options)
+			require.Nil(t, err)
+			require.NotNil(t, gotFrame)
+			require.Len(t, gotFrame.Fields, len(tt.want))
+			for _, field := range gotFrame.Fields {
+				want, ok := tt.want[field.Name]
+				require.True(t, ok, field.Name)
+				require.Equal(t, len(want), field.Len())
+				for i, v := range want {
+					assert.Equal(t, v, *(field.At(i).(*string)))
+				}
+			}
+		})
+	}
+}
+
+func TestToFrameWithXPath(t *testing.T) {
+	usersXML := `<?xml version="1.0"?>
+	<data>
+		<users>
+			<user id="1" active="true"><name lang="en">foo</name><age>30</age><tags><tag>a</tag><tag>b</tag></tags></user>
+			<user id="2" active="false"><name lang="fr">bar</name><age>40</age></user>
+		</users>
+		<groups><group id="g1"/></groups>
+	</data>`
+	t.Run("root selector and column selectors should be evaluated as xpath", func(t *testing.T) {
+		gotFrame, err := xmlframer.ToFrame(usersXML, xmlframer.FramerOptions{
+			FramerType:   xmlframer.FramerTypeXPath,
+			FrameName:    "users",
+			RootSelector: "//users/user",
+			Columns: []jsonframer.ColumnSelector{
+				{Selector: "@id", Alias: "id"},
+				{Selector: "name"},
+				{Selector: "name/@lang", Alias: "lang"},
+				{Selector: "number(age) * 2", Alias: "double age", Type: "number"},
+				{Selector: "count(tags/tag)", Alias: "tags"},
+				{Selector: "@active = 'true'", Alias: "active"},
+				{Selector: "missing", Alias: "missing"},
+			},
+		})
+		require.Nil(t, err)
+		require.NotNil(t, gotFrame)
+		assert.Equal(t, "users", gotFrame.Name)
+		require.Len(t, gotFrame.Fields, 7)
+		fieldAt := func(name string, row int) any {
+			field, idx := gotFrame.FieldByName(name)
+			require.NotEqual(t, -1, idx, name)
+			return field.At(row)
+		}
+		assert.Equal(t, "1", *(fieldAt("id", 0).(*string)))
+		assert.Equal(t, "bar", *(fieldAt("name", 1).(*string)))
+		assert.Equal(t, "fr", *(fieldAt("lang", 1).(*string)))
+		assert.Equal(t, float64(80), *(fieldAt("double age", 1).(*float64)))
+		assert.Equal(t, float64(2), *(fieldAt("tags", 0).(*float64)))
+		assert.Equal(t, float64(0), *(fieldAt("tags", 1).(*float64)))
+		assert.Equal(t, true, *(fieldAt("active", 0).(*bool)))
+		assert.Equal(t, false, *(fieldAt("active", 1).(*bool)))
+		assert.Nil(t, fieldAt("missing", 0))
+	})
+	t.Run("root selector without columns should convert the selected elements", func(t *testing.T) {
+		gotFrame, err := xmlframer.ToFrame(usersXML, xmlframer.FramerOptions{
+			FramerType:   xmlframer.FramerTypeXPath,
+			RootSelector: "/data/users/user",
+		})
+		require.Nil(t, err)
+		require.NotNil(t, gotFrame)
+		require.Equal(t, 2, gotFrame.Rows())
+		field, idx := gotFrame.FieldByName("-id")
+		require.NotEqual(t, -1, idx)
+		assert.Equal(t, "2", *(field.At(1).(*string)))
+	})
+	t.Run("root selector selecting attributes should produce single column", func(t *testing.T) {
+		gotFrame, err := xmlframer.ToFrame(usersXML, xmlframer.FramerOptions{
+			FramerType:   xmlframer.FramerTypeXPath,
+			FrameName:    "ids",
+			RootSelector: "//@id",
+		})
+		require.Nil(t, err)
+		require.NotNil(t, gotFrame)
+		require.Len(t, gotFrame.Fields, 1)
+		require.Equal(t, 3, gotFrame.Rows())
+		assert.Equal(t, "g1", *(gotFrame.Fields[0].At(2).(*string)))
+	})
+	t.Run("invalid xpath should return error", func(t *testing.T) {
+		_, err := xmlframer.ToFrame(usersXML, xmlframer.FramerOptions{
+			FramerType:   xmlframer.FramerTypeXPath,
+			RootSelector: "//users/user[",
+		})
+		require.NotNil(t, err)
+		assert.ErrorIs(t, err, xmlframer.ErrInvalidXPathSelector)
+	})
+}
+
+func TestToFrameWithTypedConversion(t *testing.T) {
+	itemsXML := `<items>
+		<item id="1"><price>10.5</price><active>true</active><zip>01234</zip><code>abc</code><created>2024-01-02</created></item>
+		<item id="2"><price>-2e3</price><active>false</active><zip>98765</zip><code>42</code><created>2024-01-03</created></item>
+		<item id="3"><price></price><active>TRUE</active><zip>11111</zip><code>x</code><created>2024-01-04</created></item>
+	</items>`
+	tests := []struct {
+		name    string
+		options xmlframer.FramerOptions
+		check   func(t *testing.T, fieldAt func(name string, row int) any)
+	}{
+		{
+			name:    "values should be strings without typed conversion",
+			options: xmlframer.FramerOptions{RootSelector: "items.item"},
+			check: func(t *testing.T, fieldAt func(name string, row int) any) {
+				assert.Equal(t, "10.5", *(fieldAt("price", 0).(*string)))
+				assert.Equal(t, "true", *(fieldAt("active", 0).(*string)))
+			},
+		},
+		{
+			name:    "numbers and booleans should be detected with typed conversion",
+			options: xmlframer.FramerOptions{RootSelector: "items.item", TypedConversion: true},
+			check: func(t *testing.T, fieldAt func(name string, row int) any) {
+				assert.Equal(t, float64(1), *(fieldAt("-id", 0).(*float64)))
+				assert.Equal(t, 10.5, *(fieldAt("price", 0).(*float64)))
+				assert.Equal(t, float64(-2000), *(fieldAt("price", 1).(*float64)))
+				assert.Nil(t, fieldAt("price", 2))
+				assert.Equal(t, true, *(fieldAt("active", 0).(*bool)))
+				assert.Equal(t, false, *(fieldAt("active", 1).(*bool)))
+				assert.Equal(t, true, *(fieldAt("active", 2).(*bool)))
+				assert.Equal(t, "01234", *(fieldAt("zip", 0).(*string)))
+				assert.Equal(t, "42", *(fieldAt("code", 1).(*string)))
+			},
+		},
+		{
+			name: "explicit column types should be honored with typed conversion",
+			options: xmlframer.FramerOptions{RootSelector: "items.item", TypedConversion: true, Columns: []jsonframer.ColumnSelector{
+				{Selector: "-id", Alias: "id", Type: "string"},
+				{Selector: "price"},
+				{Selector: "active", Type: "boolean"},
+				{Selector: "zip", Type: "number"},
+				{Selector: "created", Type: "timestamp", TimeFormat: "2006-01-02"},
+			}},
+			check: func(t *testing.T, fieldAt func(name string, row int) any) {
+				assert.Equal(t, "1", *(fieldAt("id", 0).(*string)))
+				assert.Equal(t, 10.5, *(fieldAt("price", 0).(*float64)))
+				assert.Equal(t, false, *(fieldAt("active", 1).(*bool)))
+				assert.Equal(t, float64(1234), *(fieldAt("zip", 0).(*float64)))
+				assert.Equal(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), *(fieldAt("created", 1).(*time.Time)))
+			},
+		},
+		{
+			name: "xpath columns should be converted with typed conversion",
+			options: xmlframer.FramerOptions{FramerType: xmlframer.FramerTypeXPath, RootSelector: "//item", TypedConversion: true, Columns: []jsonframer.ColumnSelector{
+				{Selector: "@id", Alias: "id"},
+				{Selector: "active"},
+				{Selector: "zip", Type: "number"},
+			}},
+			check: func(t *testing.T, fieldAt func(name string, row int) any) {
+				assert.Equal(t, float64(2), *(fieldAt("id", 1).(*float64)))
+				assert.Equal(t, false, *(fieldAt("active", 1).(*bool)))
+				assert.Equal(t, float64(98765), *(fieldAt("zip", 1).(*float64)))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFrame, err := xmlframer.ToFrame(itemsXML, tt.options)
+			require.Nil(t, err)
+			require.NotNil(t, gotFrame)
+			tt.check(t, func(name string, row int) any {
+				field, idx := gotFrame.FieldByName(name)
+				require.NotEqual(t, -1, idx, name)
+				return field.At(row)
+			})
+		})
+	}
+}
+
+func TestToFrames(t *testing.T) {
+	rssXML := `<rss version="2.0">
+		<channel><title>one</title><item><title>a</title><views>1</views></item><item><title>b</title><views>2</views></item></channel>
+		<channel><title>two</title><item><title>c</title><views>3</views></item><item><title>d</title><views>4</views></item></channel>
+	</rss>`
+	metricsXML := `<metrics>
+		<metric><time>2024-01-01T00:00:00Z</time><host>a</host><value>1</value></metric>
+		<metric><time>2024-01-01T00:00:00Z</time><host>b</host><value>2</value></metric>
+		<metric><time>2024-01-01T00:01:00Z</time><host>a</host><value>3</value></metric>
+		<metric><time>2024-01-01T00:01:00Z</time><host>b</host><value>4</value></metric>
+	</metrics>`
+	t.Run("repeated sections should produce multiple frames", func(t *testing.T) {
+		frames, err := xmlframer.ToFrames(rssXML, xmlframer.FramerOptions{RootSelector: "rss.channel.#.item"})
+		require.Nil(t, err)
+		require.Len(t, frames, 2)
+		field, _ := frames[1].FieldByName("title")
+		require.NotNil(t, field)
+		assert.Equal(t, "c", *(field.At(0).(*string)))
+	})
+	t.Run("override columns should be respected", func(t *testing.T) {
+		frames, err := xmlframer.ToFrames(rssXML, xmlframer.FramerOptions{
+			RootSelector:    "rss.channel.#.item",
+			OverrideColumns: []jsonframer.ColumnSelector{{Selector: "views", Type: "number"}},
+		})
+		require.Nil(t, err)
+		require.Len(t, frames, 2)
+		field, _ := frames[0].FieldByName("views")
+		require.NotNil(t, field)
+		assert.Equal(t, float64(2), *(field.At(1).(*float64)))
+	})
+	t.Run("multiple numeric frames should be marked as numeric multi", func(t *testing.T) {
+		frames, err := xmlframer.ToFrames(rssXML, xmlframer.FramerOptions{
+			RootSelector: "rss.channel.#.item",
+			FrameFormat:  jsonframer.FrameFormatNumeric,
+		})
+		require.Nil(t, err)
+		require.Len(t, frames, 2)
+		assert.Equal(t, data.FrameTypeNumericMulti, frames[0].Meta.Type)
+	})
+	t.Run("long timeseries with explicit column types should be converted to wide", func(t *testing.T) {
+		frames, err := xmlframer.ToFrames(metricsXML, xmlframer.FramerOptions{
+			RootSelector: "metrics.metric",
+			FrameFormat:  jsonframer.FrameFormatTimeSeries,
+			Columns: []jsonframer.ColumnSelector{
+				{Selector: "time", Type: "timestamp"},
+				{Selector: "host", Type: "string"},
+				{Selector: "value", Type: "number"},
+			},
+		})
+		require.Nil(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, data.TimeSeriesTypeWide, frames[0].TimeSeriesSchema().Type)
+		assert.Len(t, frames[0].Fields, 3)
+		assert.Equal(t, 2, frames[0].Rows())
+	})
+	t.Run("long timeseries with typed conversion should be converted to wide", func(t *testing.T) {
+		frames, err := xmlframer.ToFrames(metricsXML, xmlframer.FramerOptions{
+			RootSelector:    "metrics.metric",
+			FrameFormat:     jsonframer.FrameFormatTimeSeries,
+			TypedConversion: true,
+			OverrideColumns: []jsonframer.ColumnSelector{{Selector: "time", Type: "timestamp"}},
+		})
+		require.Nil(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, data.TimeSeriesTypeWide, frames[0].TimeSeriesSchema().Type)
+		assert.Equal(t, data.FrameTypeTimeSeriesWide, frames[0].Meta.Type)
+		assert.Len(t, frames[0].Fields, 3)
+		assert.Equal(t, 2, frames[0].Rows())
+	})
+	t.Run("xpath should produce single frame", func(t *testing.T) {
+		frames, err := xmlframer.ToFrames(rssXML, xmlframer.FramerOptions{FramerType: xmlframer.FramerTypeXPath, RootSelector: "//item"})
+		require.Nil(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, 4, frames[0].Rows())
+	})
+}
+
+func TestToFrameWithSOAPEnvelope(t *testing.T) {
+	soap11Response := `<?xml version="1.0" encoding="utf-8"?>
+	<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="urn:example:users">
+		<soap:Header><m:RequestId>abc</m:RequestId></soap:Header>
+		<soap:Body>
+			<m:GetUsersResponse>
+				<m:user id="1"><m:name>foo</m:name></m:user>
+				<m:user id="2"><m:name>bar</m:name></m:user>
+			</m:GetUsersResponse>
+		</soap:Body>
+	</soap:Envelope>`
+	soap11Fault := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+		<soap:Body>
+			<soap:Fault>
+				<faultcode>soap:Client</faultcode>
+				<faultstring>Invalid user id</faultstring>
+				<detail><code>42</code></detail>
+			</soap:Fault>
+		</soap:Body>
+	</soap:Envelope>`
+	soap12Fault := `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+		<env:Body>
+			<env:Fault>
+				<env:Code><env:Value>env:Sender</env:Value></env:Code>
+				<env:Reason><env:Text xml:lang="en">Access denied</env:Text></env:Reason>
+				<env:Role>urn:example:gateway</env:Role>
+			</env:Fault>
+		</env:Body>
+	</env:Envelope>`
+	t.Run("soap body should be unwrapped", func(t *testing.T) {
+		gotFrame, err := xmlframer.ToFrame(soap11Response, xmlframer.FramerOptions{
+			UnwrapSOAPEnvelope: true,
+			RootSelector:       "GetUsersResponse.user",
+			Columns:            []jsonframer.ColumnSelector{{Selector: "-id", Alias: "id"}, {Selector: "name"}},
+		})
+		require.Nil(t, err)
+		require.NotNil(t, gotFrame)
+		require.Equal(t, 2, gotFrame.Rows())
+		field, _ := gotFrame.FieldByName("name")
+		require.NotNil(t, field)
+		assert.Equal(t, "bar", *(field.At(1).(*string)))
+	})
+	t.Run("soap body should be unwrapped with namespace prefix", func(t *testing.T) {
+		gotFrame, err := xmlframer.ToFrame(soap11Response, xmlframer.FramerOptions{
+			UnwrapSOAPEnvelope: true,
+			NamespaceMode:      xmlframer.NamespaceModeAlias,
+			NamespaceAliases:   map[string]string{"urn:example:users": "u"},
+			RootSelector:       "u:GetUsersResponse.u:user",
+			Columns:            []jsonframer.ColumnSelector{{Selector: "u:name", Alias: "name"}},
+		})
+		require.Nil(t, err)
+		require.NotNil(t, gotFrame)
+		require.Equal(t, 2, gotFrame.Rows())
+		assert.Equal(t, "foo", *(gotFrame.Fields[0].At(0).(*string)))
+	})
+	t.Run("soap body should be unwrapped with xpath", func(t *testing.T) {
+		gotFrame, err := xmlframer.ToFrame(soap11Response, xmlframer.FramerOptions{
+			UnwrapSOAPEnvelope: true,
+			FramerType:         xmlframer.FramerTypeXPath,
+			RootSelector:       "/m:GetUsersResponse/m:user",
+			Columns:            []jsonframer.ColumnSelector{{Selector: "@id", Alias: "id"}},
+		})
+		require.Nil(t, err)
+		require.NotNil(t, gotFrame)
+		require.Equal(t, 2, gotFrame.Rows())
+		assert.Equal(t, "2", *(gotFrame.Fields[0].At(1).(*string)))
+	})
+	t.Run("soap envelope should not be unwrapped without the option", func(t *testing.T) {
+		gotFrame, err := xmlframer.ToFrame(soap11Response, xmlframer.FramerOptions{RootSelector: "Envelope.Body.GetUsersResponse.user"})
+		require.Nil(t, err)
+		require.NotNil(t, gotFrame)
+		require.Equal(t, 2, gotFrame.Rows())
+	})
+	t.Run("non soap documents should be framed as it is", func(t *testing.T) {
+		gotFrame, err := xmlframer.ToFrame(`<users><user>foo</user><user>bar</user></users>`, xmlframer.FramerOptions{UnwrapSOAPEnvelope: true, RootSelector: "users.user"})
+		require.Nil(t, err)
+		require.NotNil(t, gotFrame)
+		require.Equal(t, 2, gotFrame.Rows())
+	})
+	t.Run("soap 1.1 fault should return error", func(t *testing.T) {
+		_, err := xmlframer.ToFrame(soap11Fault, xmlframer.FramerOptions{UnwrapSOAPEnvelope: true})
+		require.NotNil(t, err)
+		assert.ErrorIs(t, err, xmlframer.ErrSOAPFault)
+		var fault *xmlframer.SOAPFault
+		require.ErrorAs(t, err, &fault)
+		assert.Equal(t, &xmlframer.SOAPFault{FaultCode: "soap:Client", FaultString: "Invalid user id", Detail: "<code>42</code>"}, fault)
+		assert.Equal(t, "soap fault received. faultcode: soap:Client, faultstring: Invalid user id", err.Error())
+	})
+	t.Run("soap 1.2 fault should return error", func(t *testing.T) {
+		_, err := xmlframer.ToFrames(soap12Fault, xmlframer.FramerOptions{UnwrapSOAPEnvelope: true})
+		require.NotNil(t, err)
+		var fault *xmlframer.SOAPFault
+		require.ErrorAs(t, err, &fault)
+		assert.Equal(t, &xmlframer.SOAPFault{FaultCode: "env:Sender", FaultString: "Access denied", FaultActor: "urn:example:gateway"}, fault)
+	})
+}
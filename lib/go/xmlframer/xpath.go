@@ -0,0 +1,101 @@
+package xmlframer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/grafana/infinity-libs/lib/go/jsonframer"
+)
+
+const FramerTypeXPath = "xpath"
+
+// applyXPathSelectors evaluates the root selector and column selectors as XPath expressions against the xml document.
+// It returns the selected rows as json string along with the column selectors to be used with jsonframer.
+func applyXPathSelectors(xmlString string, options FramerOptions) (string, []jsonframer.ColumnSelector, error) {
+	doc, err := xmlquery.Parse(strings.NewReader(xmlString))
+	if err != nil {
+		return "", nil, errors.Join(ErrConvertingXMLToFrame, err)
+	}
+	rootSelector := options.RootSelector
+	if strings.TrimSpace(rootSelector) == "" {
+		rootSelector = "/*"
+	}
+	nodes, err := xmlquery.QueryAll(doc, rootSelector)
+	if err != nil {
+		return "", nil, errors.Join(ErrInvalidXPathSelector, fmt.Errorf("root selector %q. %w", rootSelector, err))
+	}
+	rows := []any{}
+	if len(options.Columns) == 0 {
+		for _, n := range nodes {
+			rows = append(rows, nodeValue(n, options))
+		}
+		out, err := json.Marshal(rows)
+		return string(out), nil, err
+	}
+	columns := []jsonframer.ColumnSelector{}
+	expressions := []*xpath.Expr{}
+	for idx, col := range options.Columns {
+		expr, err := xpath.Compile(col.Selector)
+		if err != nil {
+			return "", nil, errors.Join(ErrInvalidXPathSelector, fmt.Errorf("column selector %q. %w", col.Selector, err))
+		}
+		expressions = append(expressions, expr)
+		name := col.Alias
+		if name == "" {
+			name = col.Selector
+		}
+		columns = append(columns, jsonframer.ColumnSelector{Selector: columnKey(idx), Alias: name, Type: col.Type, TimeFormat: col.TimeFormat})
+	}
+	for _, n := range nodes {
+		row := map[string]any{}
+		for idx, expr := range expressions {
+			row[columnKey(idx)] = evaluateXPath(expr, n)
+		}
+		rows = append(rows, row)
+	}
+	out, err := json.Marshal(rows)
+	return string(out), columns, err
+}
+
+// columnKey returns the intermediate json key of the column. Index based keys are used
+// as the XPath selectors may contain characters with special meaning in GJSON
+func columnKey(idx int) string {
+	return fmt.Sprintf("column%d", idx)
+}
+
+// nodeValue converts the selected element into json compatible value. Attributes and text nodes are converted into string
+func nodeValue(n *xmlquery.Node, options FramerOptions) any {
+	if n.Type != xmlquery.ElementNode {
+		return n.InnerText()
+	}
+	if v, ok := newConverter(options).value(strings.NewReader(n.OutputXML(true))).(map[string]any); ok {
+		for _, item := range v {
+			return item
+		}
+	}
+	return nil
+}
+
+// evaluateXPath evaluates the expression relative to the given node.
+// For node sets, the string value of the first node is returned.
+func evaluateXPath(expr *xpath.Expr, n *xmlquery.Node) any {
+	switch v := expr.Evaluate(xmlquery.CreateXPathNavigator(n)).(type) {
+	case *xpath.NodeIterator:
+		if v.MoveNext() {
+			return v.Current().Value()
+		}
+		return nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return v
+	default:
+		return v
+	}
+}